@@ -0,0 +1,361 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+var ErrAuditFailed = errors.New("audit record not written")
+
+const (
+	defaultAuditCollection   = "audit_log"
+	defaultAuditMaxDocuments = 1000
+)
+
+// AuditRecord is the document written to the audit collection for every document changed by an audited Dao
+// Changes lists the changed fields with dotted paths for embedded documents, Before is empty for created
+// documents and After for deleted ones.
+type AuditRecord struct {
+	ID         bson.ObjectID `bson:"_id,omitempty"`
+	Collection string        `bson:"collection"`
+	Operation  string        `bson:"operation"`
+	DocumentID interface{}   `bson:"document_id"`
+	Actor      string        `bson:"actor,omitempty"`
+	Timestamp  time.Time     `bson:"timestamp"`
+	Filter     interface{}   `bson:"filter,omitempty"`
+	Changes    []AuditChange `bson:"changes"`
+}
+
+type AuditChange struct {
+	Field  string      `bson:"field"`
+	Before interface{} `bson:"before,omitempty"`
+	After  interface{} `bson:"after,omitempty"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying actor, recorded in the audit records of the writes made with it
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor
+func ActorFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok && actor != ""
+}
+
+// auditTrail is the interceptor writing an AuditRecord for every document changed by op
+// The single document writes capture the document they change as it was before the write (see Operation.Capture),
+// the documents matching the filter of the others are read before the write, at most MaxDocuments of them.
+// The changed documents are read again after the write, by _id, to diff them. The ModifiedCount of a captured
+// updateOne or replaceOne is then set from that diff, it is 0 when the write left the document unchanged.
+// Inside a Session the reads and the records are part of the transaction. A write that cannot be audited
+// fails with ErrAuditFailed, the write itself is only rolled back when it ran in a transaction.
+func (d *Dao) auditTrail(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+	if !audited(op.Name) {
+		return next(ctx, op)
+	}
+	var (
+		before []bson.M
+		err    error
+	)
+	captured := op.Capture()
+	if !captured {
+		if before, err = d.auditBefore(ctx, op); err != nil {
+			return fmt.Errorf("%s: %w: %v", d.colName, ErrAuditFailed, err)
+		}
+	}
+	if err := next(ctx, op); err != nil {
+		return err
+	}
+	if captured && op.Before != nil {
+		var doc bson.M
+		if err := bson.Unmarshal(op.Before, &doc); err != nil {
+			return fmt.Errorf("%s: %w: %v", d.colName, ErrAuditFailed, err)
+		}
+		before = []bson.M{doc}
+	}
+
+	records, err := d.auditRecords(ctx, op, before)
+	if res, ok := op.Result.(*mongo.UpdateResult); ok && captured && err == nil {
+		res.ModifiedCount = int64(len(records))
+	}
+	if err == nil && len(records) > 0 {
+		_, err = op.Collection.Database().Collection(d.auditCollection()).InsertMany(ctx, records)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w: %v", d.colName, ErrAuditFailed, err)
+	}
+	return nil
+}
+
+func audited(name string) bool {
+	switch name {
+	case "insertOne", "insertMany", "updateOne", "updateMany", "replaceOne", "findOneAndUpdate", "deleteOne", "deleteMany", "bulkWrite":
+		return true
+	}
+	return false
+}
+
+func (d *Dao) auditCollection() string {
+	if d.option.Audit.Collection != "" {
+		return d.option.Audit.Collection
+	}
+	return defaultAuditCollection
+}
+
+func (d *Dao) auditMaxDocuments() int {
+	if d.option.Audit.MaxDocuments > 0 {
+		return d.option.Audit.MaxDocuments
+	}
+	return defaultAuditMaxDocuments
+}
+
+// auditPrimary returns the collection of op reading from the primary, so that the images are up to date
+func auditPrimary(op *clause.Operation) *mongo.Collection {
+	return op.Collection.Clone(options.Collection().SetReadPreference(readpref.Primary()))
+}
+
+// auditBefore reads the documents op is about to change, nil for inserts
+// It fails when updateMany, deleteMany or bulkWrite would change more than the audit MaxDocuments.
+// The documents matching the filter of any model of a bulkWrite are read, those left unchanged get no record.
+func (d *Dao) auditBefore(ctx context.Context, op *clause.Operation) ([]bson.M, error) {
+	filter := op.Filter
+	if filter == nil {
+		filter = bson.D{}
+	}
+	collection := auditPrimary(op)
+
+	switch op.Name {
+	case "insertOne", "insertMany":
+		return nil, nil
+	case "bulkWrite":
+		filters := bson.A{}
+		for _, model := range op.Models {
+			if filter := modelFilter(model); filter != nil {
+				filters = append(filters, filter)
+			}
+		}
+		if len(filters) == 0 {
+			return nil, nil
+		}
+		filter = bson.M{"$or": filters}
+		fallthrough
+	case "updateMany", "deleteMany":
+		max := d.auditMaxDocuments()
+		cursor, err := collection.Find(ctx, filter, options.Find().SetLimit(int64(max)+1))
+		if err != nil {
+			return nil, err
+		}
+		var docs []bson.M
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, err
+		}
+		if len(docs) > max {
+			return nil, fmt.Errorf("%s matches more than %d documents", op.Name, max)
+		}
+		return docs, nil
+	}
+
+	opts := options.FindOne()
+	if sort := op.Sort(); sort != nil {
+		opts.SetSort(sort)
+	}
+	var doc bson.M
+	err := collection.FindOne(ctx, filter, opts).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []bson.M{doc}, nil
+}
+
+// auditRecords builds the records of op from the documents read before it and those read after it
+func (d *Dao) auditRecords(ctx context.Context, op *clause.Operation, before []bson.M) ([]interface{}, error) {
+	var actor string
+	if d.option.Audit.Actor != nil {
+		actor, _ = d.option.Audit.Actor(ctx)
+	} else {
+		actor, _ = ActorFromContext(ctx)
+	}
+	now := time.Now()
+	record := func(id interface{}, filter interface{}, before bson.M, after bson.M) *AuditRecord {
+		changes := auditChanges(before, after)
+		if len(changes) == 0 {
+			return nil
+		}
+		return &AuditRecord{
+			Collection: d.colName,
+			Operation:  op.Name,
+			DocumentID: id,
+			Actor:      actor,
+			Timestamp:  now,
+			Filter:     filter,
+			Changes:    changes,
+		}
+	}
+
+	var records []interface{}
+	switch res := op.Result.(type) {
+	case *mongo.InsertOneResult:
+		doc, err := utils.ConvToBson(op.Documents[0])
+		if err != nil {
+			return nil, err
+		}
+		if rec := record(res.InsertedID, nil, nil, doc); rec != nil {
+			records = append(records, rec)
+		}
+		return records, nil
+	case *mongo.InsertManyResult:
+		for i, id := range res.InsertedIDs {
+			if i >= len(op.Documents) {
+				break
+			}
+			doc, err := utils.ConvToBson(op.Documents[i])
+			if err != nil {
+				return nil, err
+			}
+			if rec := record(id, nil, nil, doc); rec != nil {
+				records = append(records, rec)
+			}
+		}
+		return records, nil
+	}
+
+	ids := make([]interface{}, 0, len(before)+1)
+	for _, doc := range before {
+		ids = append(ids, doc["_id"])
+	}
+	switch res := op.Result.(type) {
+	case *mongo.UpdateResult:
+		if res.UpsertedID != nil {
+			ids = append(ids, res.UpsertedID)
+		}
+	case *mongo.BulkWriteResult:
+		for _, model := range op.Models {
+			if insert, ok := model.(*mongo.InsertOneModel); ok {
+				if doc, ok := insert.Document.(bson.M); ok && doc["_id"] != nil {
+					ids = append(ids, doc["_id"])
+				}
+			}
+		}
+		for _, id := range res.UpsertedIDs {
+			ids = append(ids, id)
+		}
+	case *mongo.SingleResult:
+		// the upserted document is only known from the document returned by findOneAndUpdate
+		if len(before) == 0 {
+			if raw, err := res.Raw(); err == nil {
+				if id, err := raw.LookupErr("_id"); err == nil {
+					var value interface{}
+					if err := id.Unmarshal(&value); err == nil {
+						ids = append(ids, value)
+					}
+				}
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := auditPrimary(op).Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var after []bson.M
+	if err := cursor.All(ctx, &after); err != nil {
+		return nil, err
+	}
+
+	beforeByID := make(map[string]bson.M, len(before))
+	for _, doc := range before {
+		beforeByID[auditKey(doc["_id"])] = doc
+	}
+	afterByID := make(map[string]bson.M, len(after))
+	for _, doc := range after {
+		afterByID[auditKey(doc["_id"])] = doc
+	}
+	for _, id := range ids {
+		key := auditKey(id)
+		if rec := record(id, op.Filter, beforeByID[key], afterByID[key]); rec != nil {
+			records = append(records, rec)
+		}
+	}
+	return records, nil
+}
+
+// modelFilter returns the filter of a write model, nil for inserts
+func modelFilter(model mongo.WriteModel) interface{} {
+	var filter interface{}
+	switch m := model.(type) {
+	case *mongo.InsertOneModel:
+		return nil
+	case *mongo.UpdateOneModel:
+		filter = m.Filter
+	case *mongo.UpdateManyModel:
+		filter = m.Filter
+	case *mongo.ReplaceOneModel:
+		filter = m.Filter
+	case *mongo.DeleteOneModel:
+		filter = m.Filter
+	case *mongo.DeleteManyModel:
+		filter = m.Filter
+	}
+	if filter == nil {
+		return bson.D{}
+	}
+	return filter
+}
+
+func auditKey(id interface{}) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
+
+// auditChanges lists the fields differing between before and after, sorted by path
+func auditChanges(before bson.M, after bson.M) []AuditChange {
+	var changes []AuditChange
+	update := diffDocument(before, after)
+	if set, ok := update["$set"].(bson.M); ok {
+		for path, value := range set {
+			changes = append(changes, AuditChange{Field: path, Before: pathValue(before, path), After: value})
+		}
+	}
+	if unset, ok := update["$unset"].(bson.M); ok {
+		for path := range unset {
+			changes = append(changes, AuditChange{Field: path, Before: pathValue(before, path)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// pathValue returns the value at the dotted path of doc, nil when it has none
+func pathValue(doc bson.M, path string) interface{} {
+	var value interface{} = doc
+	for _, key := range strings.Split(path, ".") {
+		embedded, ok := asDocument(value)
+		if !ok {
+			return nil
+		}
+		value = embedded[key]
+	}
+	return value
+}
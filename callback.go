@@ -0,0 +1,85 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nghialthanh/morn-go/logger"
+)
+
+var ErrNoSession = errors.New("context is not bound to a morn session")
+
+type txnCallbacksKey struct{}
+
+// txnCallbacks holds the functions queued by OnCommit and OnRollback for one Session
+type txnCallbacks struct {
+	mu         sync.Mutex
+	onCommit   []func(ctx context.Context) error
+	onRollback []func(ctx context.Context) error
+}
+
+// OnCommit queues fn to run after the transaction bound to ctx commits successfully
+// Callbacks run in registration order with the context passed to Dao.Session
+// Warning:
+// - ctx must be the session context received by the Session callback
+// - Errors and panics inside fn are logged and never fail the transaction
+func OnCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	callbacks, ok := ctx.Value(txnCallbacksKey{}).(*txnCallbacks)
+	if !ok || callbacks == nil {
+		return ErrNoSession
+	}
+	callbacks.mu.Lock()
+	callbacks.onCommit = append(callbacks.onCommit, fn)
+	callbacks.mu.Unlock()
+	return nil
+}
+
+// OnRollback queues fn to run after the transaction bound to ctx is aborted
+// This happens when the Session callback returns an error or the commit fails
+// Warning:
+// - ctx must be the session context received by the Session callback
+// - Errors and panics inside fn are logged and never change the error returned by Session
+func OnRollback(ctx context.Context, fn func(ctx context.Context) error) error {
+	callbacks, ok := ctx.Value(txnCallbacksKey{}).(*txnCallbacks)
+	if !ok || callbacks == nil {
+		return ErrNoSession
+	}
+	callbacks.mu.Lock()
+	callbacks.onRollback = append(callbacks.onRollback, fn)
+	callbacks.mu.Unlock()
+	return nil
+}
+
+func withTxnCallbacks(ctx context.Context) (context.Context, *txnCallbacks) {
+	callbacks := &txnCallbacks{}
+	return context.WithValue(ctx, txnCallbacksKey{}, callbacks), callbacks
+}
+
+// run executes the commit or rollback queue in registration order
+func (t *txnCallbacks) run(ctx context.Context, committed bool, log logger.ILogger) {
+	t.mu.Lock()
+	queue := t.onRollback
+	name := "rollback"
+	if committed {
+		queue = t.onCommit
+		name = "commit"
+	}
+	t.mu.Unlock()
+
+	for i, fn := range queue {
+		if err := safeCallback(ctx, fn); err != nil {
+			log.Errorf("after-%s callback #%d failed: %v", name, i+1, err)
+		}
+	}
+}
+
+func safeCallback(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,67 @@
+package morn
+
+import (
+	"context"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// CausalSession starts a causally consistent session without a transaction
+// and returns a context bound to it, so every Clause operation using that context
+// observes the writes made before it (read-your-writes, monotonic reads).
+// The returned release function ends the session and must always be called.
+// If ctx is already bound to a session it is returned unchanged with a no-op release.
+// Warning:
+// - Guarantees across primary and secondaries require majority read and write concern
+func (i *Instance) CausalSession(ctx context.Context) (context.Context, func(), error) {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	if mongo.SessionFromContext(ctx) != nil {
+		return ctx, func() {}, nil
+	}
+
+	session, err := i.client.StartSession(options.Session().SetCausalConsistency(true))
+	if err != nil {
+		i.logger.Error("Failed to start causal session", err)
+		return ctx, func() {}, err
+	}
+
+	ctxTracked, untrack, err := i.trackSession(ctx, session)
+	if err != nil {
+		session.EndSession(ctx)
+		return ctx, func() {}, err
+	}
+	release := func() {
+		session.EndSession(context.WithoutCancel(ctx))
+		untrack(false)
+	}
+	return mongo.NewSessionContext(ctxTracked, session), release, nil
+}
+
+// Causal runs f with a context bound to a causally consistent session
+// The session is ended when f returns
+func (i *Instance) Causal(ctx context.Context, f func(ctx context.Context) error) error {
+	ctxSession, release, err := i.CausalSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return f(ctxSession)
+}
+
+// CausalMiddleware runs every HTTP request in its own causally consistent session
+// Handlers get the session through r.Context() and pass it to Dao.Ctx
+func (i *Instance) CausalMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, release, err := i.CausalSession(r.Context())
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -1,6 +1,8 @@
 package clause
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -12,17 +14,13 @@ func (c *Clause) MAggregate(entity interface{}, pipeline []bson.M) error {
 		opts = c.opts.ToAggregate()
 	}
 
-	res, err := c.collection.Aggregate(c.ctx, pipeline, opts)
-	if err != nil {
-		return err
-	}
-
-	err = c.convResultToObj(entity, res)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	op := &Operation{Kind: KindAggregate, Name: "aggregate", Pipeline: pipeline, Options: opts}
+	return c.run(op, c.aggregate(opts), func(ctx context.Context, op *Operation) error {
+		if _, ok := resultAs[*mongo.Cursor](op); !ok {
+			return ErrNoResult
+		}
+		return c.decodeInto(ctx, op, entity)
+	})
 }
 
 func (c *Clause) Aggregate(pipeline []bson.M) (*mongo.Cursor, error) {
@@ -31,10 +29,26 @@ func (c *Clause) Aggregate(pipeline []bson.M) (*mongo.Cursor, error) {
 		opts = c.opts.ToAggregate()
 	}
 
-	res, err := c.collection.Aggregate(c.ctx, pipeline, opts)
+	op := &Operation{Kind: KindAggregate, Name: "aggregate", Pipeline: pipeline, Options: opts}
+	err := c.run(op, c.aggregate(opts), nil)
 	if err != nil {
 		return nil, err
 	}
 
+	res, ok := resultAs[*mongo.Cursor](op)
+	if !ok {
+		return nil, ErrNoResult
+	}
 	return res, nil
 }
+
+func (c *Clause) aggregate(opts *options.AggregateOptionsBuilder) Invoker {
+	return func(ctx context.Context, op *Operation) error {
+		res, err := op.Collection.Aggregate(ctx, op.Pipeline, optionsAs(op, opts))
+		if err != nil {
+			return err
+		}
+		op.Result = res
+		return nil
+	}
+}
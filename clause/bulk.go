@@ -0,0 +1,65 @@
+package clause
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nghialthanh/morn-go/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// BulkWrite runs models in one ordered bulk write, through the policies and interceptors of the clause
+// Supported models are InsertOneModel, UpdateOneModel, UpdateManyModel, ReplaceOneModel, DeleteOneModel and
+// DeleteManyModel. Inserted documents without an _id get a new ObjectID, so that interceptors know the documents
+// they create. The models of a scoped clause are scoped like the other writes, the given models are not modified.
+// Warning:
+// - Hooks do not run, the models are not entities
+func (c *Clause) BulkWrite(models []mongo.WriteModel) (*mongo.BulkWriteResult, error) {
+	opts := options.BulkWrite().SetOrdered(true)
+
+	models, err := identifyInserts(models)
+	if err != nil {
+		return nil, err
+	}
+	op := &Operation{Kind: KindWrite, Name: "bulkWrite", Models: models, Options: opts}
+	err = c.run(op, func(ctx context.Context, op *Operation) error {
+		res, err := op.Collection.BulkWrite(ctx, op.Models, optionsAs(op, opts))
+		if err != nil {
+			return err
+		}
+		op.Result = res
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resultAs[*mongo.BulkWriteResult](op)
+	if !ok {
+		return nil, ErrNoResult
+	}
+	return res, nil
+}
+
+// identifyInserts returns models with the documents of the InsertOneModels rendered as bson.M holding an _id
+func identifyInserts(models []mongo.WriteModel) ([]mongo.WriteModel, error) {
+	result := make([]mongo.WriteModel, len(models))
+	for i, model := range models {
+		insert, ok := model.(*mongo.InsertOneModel)
+		if !ok {
+			result[i] = model
+			continue
+		}
+		doc, err := utils.ConvToBson(insert.Document)
+		if err != nil {
+			return nil, fmt.Errorf("model %d: %w", i, err)
+		}
+		if id, ok := doc["_id"]; !ok || id == nil {
+			doc["_id"] = bson.NewObjectID()
+		}
+		result[i] = &mongo.InsertOneModel{Document: doc}
+	}
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package clause
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Capture makes op keep the document it changes, as it was before the write, in op.Before
+// updateOne, replaceOne and deleteOne then run as findOneAndUpdate, findOneAndReplace and findOneAndDelete and
+// their result is rebuilt from the returned document. findOneAndUpdate keeps the document it returns when it
+// returns the document before the update.
+// Warning:
+//   - The server does not report whether the document found was modified, the ModifiedCount of a captured
+//     update is its MatchedCount until the caller compares op.Before with the document after the write,
+//     as the audit trail of the Dao does
+//
+// Capture returns false and leaves op unchanged for other operations and for upserts.
+func (op *Operation) Capture() bool {
+	switch op.Name {
+	case "updateOne":
+		opts, err := listOptions[options.UpdateOneOptions](op.Options)
+		if err != nil || (opts.Upsert != nil && *opts.Upsert) {
+			return false
+		}
+	case "replaceOne":
+		opts, err := listOptions[options.ReplaceOptions](op.Options)
+		if err != nil || (opts.Upsert != nil && *opts.Upsert) {
+			return false
+		}
+	case "findOneAndUpdate":
+		opts, err := listOptions[options.FindOneAndUpdateOptions](op.Options)
+		if err != nil || (opts.ReturnDocument != nil && *opts.ReturnDocument == options.After) {
+			return false
+		}
+	case "deleteOne":
+	default:
+		return false
+	}
+	op.capture = true
+	return true
+}
+
+// captureUpdate runs the captured updateOne or replaceOne op and returns its result
+func (op *Operation) captureUpdate(ctx context.Context) (*mongo.UpdateResult, error) {
+	var res *mongo.SingleResult
+	if op.Name == "replaceOne" {
+		o, err := listOptions[options.ReplaceOptions](op.Options)
+		if err != nil {
+			return nil, err
+		}
+		opts := options.FindOneAndReplace().SetReturnDocument(options.Before)
+		if o.BypassDocumentValidation != nil {
+			opts.SetBypassDocumentValidation(*o.BypassDocumentValidation)
+		}
+		if o.Collation != nil {
+			opts.SetCollation(o.Collation)
+		}
+		if o.Comment != nil {
+			opts.SetComment(o.Comment)
+		}
+		if o.Hint != nil {
+			opts.SetHint(o.Hint)
+		}
+		if o.Let != nil {
+			opts.SetLet(o.Let)
+		}
+		if o.Sort != nil {
+			opts.SetSort(o.Sort)
+		}
+		res = op.Collection.FindOneAndReplace(ctx, op.Filter, op.Documents[0], opts)
+	} else {
+		o, err := listOptions[options.UpdateOneOptions](op.Options)
+		if err != nil {
+			return nil, err
+		}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+		if o.ArrayFilters != nil {
+			opts.SetArrayFilters(o.ArrayFilters)
+		}
+		if o.BypassDocumentValidation != nil {
+			opts.SetBypassDocumentValidation(*o.BypassDocumentValidation)
+		}
+		if o.Collation != nil {
+			opts.SetCollation(o.Collation)
+		}
+		if o.Comment != nil {
+			opts.SetComment(o.Comment)
+		}
+		if o.Hint != nil {
+			opts.SetHint(o.Hint)
+		}
+		if o.Let != nil {
+			opts.SetLet(o.Let)
+		}
+		if o.Sort != nil {
+			opts.SetSort(o.Sort)
+		}
+		res = op.Collection.FindOneAndUpdate(ctx, op.Filter, op.Update, opts)
+	}
+
+	matched, err := op.keepBefore(res)
+	if err != nil {
+		return nil, err
+	}
+	return &mongo.UpdateResult{MatchedCount: matched, ModifiedCount: matched, Acknowledged: true}, nil
+}
+
+// captureDelete runs the captured deleteOne op and returns its result
+func (op *Operation) captureDelete(ctx context.Context) (*mongo.DeleteResult, error) {
+	o, err := listOptions[options.DeleteOneOptions](op.Options)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.FindOneAndDelete()
+	if o.Collation != nil {
+		opts.SetCollation(o.Collation)
+	}
+	if o.Comment != nil {
+		opts.SetComment(o.Comment)
+	}
+	if o.Hint != nil {
+		opts.SetHint(o.Hint)
+	}
+	if o.Let != nil {
+		opts.SetLet(o.Let)
+	}
+
+	deleted, err := op.keepBefore(op.Collection.FindOneAndDelete(ctx, op.Filter, opts))
+	if err != nil {
+		return nil, err
+	}
+	return &mongo.DeleteResult{DeletedCount: deleted, Acknowledged: true}, nil
+}
+
+// keepBefore stores the document returned by res in op.Before and returns the number of documents it matched
+func (op *Operation) keepBefore(res *mongo.SingleResult) (int64, error) {
+	raw, err := res.Raw()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		op.Before = nil
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	op.Before = bson.Raw(append([]byte(nil), raw...))
+	return 1, nil
+}
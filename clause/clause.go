@@ -2,11 +2,16 @@ package clause
 
 import (
 	"context"
+	"time"
 
 	"github.com/nghialthanh/morn-go/logger"
 	"github.com/nghialthanh/morn-go/option"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
 )
 
 type Clause struct {
@@ -23,6 +28,23 @@ type Clause struct {
 	limit     int
 	sort      bson.M
 	opts      *option.QueryOption
+	scope     bson.M
+	err       error
+	gates     []Gate
+	collOpts  *options.CollectionOptionsBuilder
+	routes    map[OpKind][]*mongo.Collection
+	timeout   *time.Duration
+	retry     *option.RetryPolicy
+	retrySet  bool
+	model     interface{}
+
+	interceptors []Interceptor
+	onRetry      func(ctx context.Context, op *Operation, attempt int, err error)
+	onReject     func(ctx context.Context, op *Operation, err error)
+
+	dryRun    bool
+	statement *Statement
+	prepared  *Prepared
 }
 
 func NewClause(
@@ -52,11 +74,13 @@ func (c *Clause) Where(condition interface{}) *Clause {
 
 func (c *Clause) Limit(limit int) *Clause {
 	c.limit = limit
+	c.prepared = nil
 	return c
 }
 
 func (c *Clause) Skip(offset int) *Clause {
 	c.offset = offset
+	c.prepared = nil
 	return c
 }
 
@@ -65,6 +89,7 @@ func (c *Clause) Skip(offset int) *Clause {
 func (c *Clause) Page(page int, limit int) *Clause {
 	c.offset = page
 	c.limit = limit
+	c.prepared = nil
 	return c
 }
 
@@ -82,10 +107,100 @@ func (c *Clause) Sort(sort string) *Clause {
 		return c
 	}
 	c.sort = sortFields
+	c.prepared = nil
 	return c
 }
 
 func (c *Clause) Option(opts option.QueryOption) *Clause {
 	c.opts = &opts
+	c.prepared = nil
+	return c
+}
+
+// Scope restricts the clause to the documents matching scope
+// The scope is ANDed into every filter, set on every inserted document,
+// matched at the start of aggregate pipelines and cannot be changed by updates.
+// The pipelines of $lookup, $unionWith, $graphLookup and $facet are scoped too, aggregations
+// using a stage that cannot be scoped, such as $collStats, fail with ErrUnscopedStage.
+// It is used to isolate tenants sharing a collection.
+func (c *Clause) Scope(scope bson.M) *Clause {
+	if len(scope) == 0 {
+		return c
+	}
+	if c.scope == nil {
+		c.scope = bson.M{}
+	}
+	for key, value := range scope {
+		c.scope[key] = value
+	}
+	return c
+}
+
+// Fail marks the clause as unusable, every terminal method returns err without reaching the database
+func (c *Clause) Fail(err error) *Clause {
+	c.err = err
+	return c
+}
+
+// Gate adds admission checks run before every operation of the clause, in order
+func (c *Clause) Gate(gates ...Gate) *Clause {
+	c.gates = append(c.gates, gates...)
 	return c
 }
+
+// OnReject sets fn to be called when a gate rejects an operation of the clause, err is the error of the gate
+func (c *Clause) OnReject(fn func(ctx context.Context, op *Operation, err error)) *Clause {
+	c.onReject = fn
+	return c
+}
+
+// ReadFrom sets the read preference of the clause, for example readpref.SecondaryPreferred()
+// Inside a Session transaction the read preference of the transaction is used instead
+func (c *Clause) ReadFrom(rp *readpref.ReadPref) *Clause {
+	c.collectionOptions().SetReadPreference(rp)
+	return c
+}
+
+// WithReadConcern sets the read concern of the clause
+// Inside a Session transaction the read concern of the transaction is used instead
+func (c *Clause) WithReadConcern(rc *readconcern.ReadConcern) *Clause {
+	c.collectionOptions().SetReadConcern(rc)
+	return c
+}
+
+// WithWriteConcern sets the write concern of the clause, for example writeconcern.Majority()
+// Inside a Session transaction the write concern of the transaction is used instead
+func (c *Clause) WithWriteConcern(wc *writeconcern.WriteConcern) *Clause {
+	c.collectionOptions().SetWriteConcern(wc)
+	return c
+}
+
+// Timeout bounds the operations of the clause to d, overriding the timeouts of MornOption
+// The deadline is the earliest of the context deadline and d, the driver sends it to the server as maxTimeMS.
+// For FindMany and Aggregate it covers the first batch, the returned cursor uses the context given to Next.
+// Timeout(0) removes the timeout of MornOption.
+func (c *Clause) Timeout(d time.Duration) *Clause {
+	c.timeout = &d
+	return c
+}
+
+// Route runs the operations of kind on collections instead of the collection of the clause
+// The first collection is used, the next ones are fallbacks tried in order when a read or an aggregate
+// fails because its deployment is unreachable. Writes never fall back. Index operations follow the KindWrite route.
+func (c *Clause) Route(kind OpKind, collections ...*mongo.Collection) *Clause {
+	if len(collections) == 0 {
+		return c
+	}
+	if c.routes == nil {
+		c.routes = make(map[OpKind][]*mongo.Collection)
+	}
+	c.routes[kind] = collections
+	return c
+}
+
+func (c *Clause) collectionOptions() *options.CollectionOptionsBuilder {
+	if c.collOpts == nil {
+		c.collOpts = options.Collection()
+	}
+	return c.collOpts
+}
@@ -0,0 +1,289 @@
+package clause
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Command returns the database command equivalent to op, as accepted by Database.RunCommand and explain
+// The options that change the documents read or written are rendered: sort, projection, skip, limit, hint,
+// collation, upsert and array filters. countDocuments is rendered as a count command.
+func (op *Operation) Command() (bson.D, error) {
+	collection := ""
+	if op.Collection != nil {
+		collection = op.Collection.Name()
+	}
+	filter := op.Filter
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	switch op.Name {
+	case "find":
+		opts, err := listOptions[options.FindOptions](op.Options)
+		if err != nil {
+			return nil, err
+		}
+		cmd := bson.D{{Key: "find", Value: collection}, {Key: "filter", Value: filter}}
+		cmd = appendOption(cmd, "sort", opts.Sort)
+		cmd = appendOption(cmd, "projection", opts.Projection)
+		cmd = appendOption(cmd, "skip", opts.Skip)
+		cmd = appendOption(cmd, "limit", opts.Limit)
+		cmd = appendOption(cmd, "hint", opts.Hint)
+		return appendOption(cmd, "collation", opts.Collation), nil
+	case "findOne":
+		opts, err := listOptions[options.FindOneOptions](op.Options)
+		if err != nil {
+			return nil, err
+		}
+		cmd := bson.D{{Key: "find", Value: collection}, {Key: "filter", Value: filter}}
+		cmd = appendOption(cmd, "sort", opts.Sort)
+		cmd = appendOption(cmd, "projection", opts.Projection)
+		cmd = appendOption(cmd, "skip", opts.Skip)
+		cmd = append(cmd, bson.E{Key: "limit", Value: 1}, bson.E{Key: "singleBatch", Value: true})
+		cmd = appendOption(cmd, "hint", opts.Hint)
+		return appendOption(cmd, "collation", opts.Collation), nil
+	case "countDocuments":
+		opts, err := listOptions[options.CountOptions](op.Options)
+		if err != nil {
+			return nil, err
+		}
+		cmd := bson.D{{Key: "count", Value: collection}, {Key: "query", Value: filter}}
+		cmd = appendOption(cmd, "skip", opts.Skip)
+		cmd = appendOption(cmd, "limit", opts.Limit)
+		cmd = appendOption(cmd, "hint", opts.Hint)
+		return appendOption(cmd, "collation", opts.Collation), nil
+	case "estimatedDocumentCount":
+		return bson.D{{Key: "count", Value: collection}}, nil
+	case "aggregate":
+		opts, err := listOptions[options.AggregateOptions](op.Options)
+		if err != nil {
+			return nil, err
+		}
+		pipeline := op.Pipeline
+		if pipeline == nil {
+			pipeline = []bson.M{}
+		}
+		cmd := bson.D{{Key: "aggregate", Value: collection}, {Key: "pipeline", Value: pipeline}, {Key: "cursor", Value: bson.D{}}}
+		cmd = appendOption(cmd, "allowDiskUse", opts.AllowDiskUse)
+		cmd = appendOption(cmd, "hint", opts.Hint)
+		return appendOption(cmd, "collation", opts.Collation), nil
+	case "insertOne", "insertMany":
+		return bson.D{{Key: "insert", Value: collection}, {Key: "documents", Value: op.Documents}}, nil
+	case "updateOne", "updateMany":
+		statement := bson.D{{Key: "q", Value: filter}, {Key: "u", Value: op.Update}}
+		if op.Name == "updateOne" {
+			opts, err := listOptions[options.UpdateOneOptions](op.Options)
+			if err != nil {
+				return nil, err
+			}
+			statement = appendOption(statement, "upsert", opts.Upsert)
+			statement = appendOption(statement, "arrayFilters", opts.ArrayFilters)
+			statement = appendOption(statement, "hint", opts.Hint)
+			statement = appendOption(statement, "collation", opts.Collation)
+			statement = appendOption(statement, "sort", opts.Sort)
+		} else {
+			opts, err := listOptions[options.UpdateManyOptions](op.Options)
+			if err != nil {
+				return nil, err
+			}
+			statement = appendOption(statement, "upsert", opts.Upsert)
+			statement = append(statement, bson.E{Key: "multi", Value: true})
+			statement = appendOption(statement, "arrayFilters", opts.ArrayFilters)
+			statement = appendOption(statement, "hint", opts.Hint)
+			statement = appendOption(statement, "collation", opts.Collation)
+		}
+		return bson.D{{Key: "update", Value: collection}, {Key: "updates", Value: bson.A{statement}}}, nil
+	case "replaceOne":
+		opts, err := listOptions[options.ReplaceOptions](op.Options)
+		if err != nil {
+			return nil, err
+		}
+		var replacement interface{}
+		if len(op.Documents) > 0 {
+			replacement = op.Documents[0]
+		}
+		statement := bson.D{{Key: "q", Value: filter}, {Key: "u", Value: replacement}}
+		statement = appendOption(statement, "upsert", opts.Upsert)
+		statement = appendOption(statement, "hint", opts.Hint)
+		statement = appendOption(statement, "collation", opts.Collation)
+		statement = appendOption(statement, "sort", opts.Sort)
+		return bson.D{{Key: "update", Value: collection}, {Key: "updates", Value: bson.A{statement}}}, nil
+	case "findOneAndUpdate":
+		opts, err := listOptions[options.FindOneAndUpdateOptions](op.Options)
+		if err != nil {
+			return nil, err
+		}
+		cmd := bson.D{{Key: "findAndModify", Value: collection}, {Key: "query", Value: filter}, {Key: "update", Value: op.Update}}
+		cmd = appendOption(cmd, "sort", opts.Sort)
+		cmd = appendOption(cmd, "fields", opts.Projection)
+		cmd = appendOption(cmd, "upsert", opts.Upsert)
+		if opts.ReturnDocument != nil {
+			cmd = append(cmd, bson.E{Key: "new", Value: *opts.ReturnDocument == options.After})
+		}
+		cmd = appendOption(cmd, "arrayFilters", opts.ArrayFilters)
+		cmd = appendOption(cmd, "hint", opts.Hint)
+		return appendOption(cmd, "collation", opts.Collation), nil
+	case "deleteOne", "deleteMany":
+		limit := 0
+		if op.Name == "deleteOne" {
+			limit = 1
+		}
+		statement := bson.D{{Key: "q", Value: filter}, {Key: "limit", Value: limit}}
+		if op.Name == "deleteOne" {
+			opts, err := listOptions[options.DeleteOneOptions](op.Options)
+			if err != nil {
+				return nil, err
+			}
+			statement = appendOption(statement, "hint", opts.Hint)
+			statement = appendOption(statement, "collation", opts.Collation)
+		} else {
+			opts, err := listOptions[options.DeleteManyOptions](op.Options)
+			if err != nil {
+				return nil, err
+			}
+			statement = appendOption(statement, "hint", opts.Hint)
+			statement = appendOption(statement, "collation", opts.Collation)
+		}
+		return bson.D{{Key: "delete", Value: collection}, {Key: "deletes", Value: bson.A{statement}}}, nil
+	case "createIndexes":
+		indexes := bson.A{}
+		for _, document := range op.Documents {
+			model, ok := document.(mongo.IndexModel)
+			if !ok {
+				return nil, fmt.Errorf("createIndexes: expected a mongo.IndexModel, got %T", document)
+			}
+			index, err := indexSpec(model)
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, index)
+		}
+		return bson.D{{Key: "createIndexes", Value: collection}, {Key: "indexes", Value: indexes}}, nil
+	case "dropIndexes":
+		if len(op.Documents) != 1 {
+			return nil, fmt.Errorf("dropIndexes: expected one index name, got %d", len(op.Documents))
+		}
+		return bson.D{{Key: "dropIndexes", Value: collection}, {Key: "index", Value: op.Documents[0]}}, nil
+	case "listIndexes":
+		return bson.D{{Key: "listIndexes", Value: collection}}, nil
+	}
+	return nil, fmt.Errorf("no command for operation %q", op.Name)
+}
+
+// Sort returns the sort of op, nil when its options have none
+func (op *Operation) Sort() interface{} {
+	switch op.Name {
+	case "find":
+		if opts, err := listOptions[options.FindOptions](op.Options); err == nil {
+			return opts.Sort
+		}
+	case "findOne":
+		if opts, err := listOptions[options.FindOneOptions](op.Options); err == nil {
+			return opts.Sort
+		}
+	case "replaceOne":
+		if opts, err := listOptions[options.ReplaceOptions](op.Options); err == nil {
+			return opts.Sort
+		}
+	case "findOneAndUpdate":
+		if opts, err := listOptions[options.FindOneAndUpdateOptions](op.Options); err == nil {
+			return opts.Sort
+		}
+	case "updateOne":
+		if opts, err := listOptions[options.UpdateOneOptions](op.Options); err == nil {
+			return opts.Sort
+		}
+	}
+	return nil
+}
+
+// listOptions applies the setters of an options builder, opts of another type give empty options
+func listOptions[T any](opts interface{}) (*T, error) {
+	out := new(T)
+	lister, ok := opts.(options.Lister[T])
+	if !ok {
+		return out, nil
+	}
+	for _, apply := range lister.List() {
+		if err := apply(out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// appendOption appends key to cmd unless value is nil or a nil pointer or slice
+func appendOption(cmd bson.D, key string, value interface{}) bson.D {
+	switch v := value.(type) {
+	case nil:
+		return cmd
+	case *int64:
+		if v == nil {
+			return cmd
+		}
+		return append(cmd, bson.E{Key: key, Value: *v})
+	case *bool:
+		if v == nil {
+			return cmd
+		}
+		return append(cmd, bson.E{Key: key, Value: *v})
+	case *options.Collation:
+		if v == nil {
+			return cmd
+		}
+		return append(cmd, bson.E{Key: key, Value: collationDocument(v)})
+	case []interface{}:
+		if v == nil {
+			return cmd
+		}
+	}
+	return append(cmd, bson.E{Key: key, Value: value})
+}
+
+// indexSpec renders model as an element of the indexes of createIndexes, naming it like the driver does
+func indexSpec(model mongo.IndexModel) (bson.D, error) {
+	keys, err := bson.Marshal(model.Keys)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := listOptions[options.IndexOptions](model.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	name := ""
+	if opts.Name != nil {
+		name = *opts.Name
+	} else {
+		elements, err := bson.Raw(keys).Elements()
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, 0, len(elements))
+		for _, element := range elements {
+			value := element.Value()
+			if n, ok := value.AsInt64OK(); ok {
+				parts = append(parts, fmt.Sprintf("%s_%d", element.Key(), n))
+			} else if s, ok := value.StringValueOK(); ok {
+				parts = append(parts, element.Key()+"_"+s)
+			} else {
+				parts = append(parts, element.Key()+"_"+value.String())
+			}
+		}
+		name = strings.Join(parts, "_")
+	}
+
+	spec := bson.D{{Key: "key", Value: bson.Raw(keys)}, {Key: "name", Value: name}}
+	spec = appendOption(spec, "unique", opts.Unique)
+	spec = appendOption(spec, "sparse", opts.Sparse)
+	if opts.ExpireAfterSeconds != nil {
+		spec = append(spec, bson.E{Key: "expireAfterSeconds", Value: *opts.ExpireAfterSeconds})
+	}
+	spec = appendOption(spec, "partialFilterExpression", opts.PartialFilterExpression)
+	return appendOption(spec, "collation", opts.Collation), nil
+}
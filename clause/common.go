@@ -61,8 +61,7 @@ func (c *Clause) convSorted(sort string) (bson.M, error) {
 	return bson.M{key: valueSorted}, nil
 }
 
-func (c *Clause) convResultToObj(obj interface{}, result interface{}) error {
-	ctx := c.ctx
+func (c *Clause) convResultToObj(ctx context.Context, obj interface{}, result interface{}) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
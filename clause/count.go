@@ -1,6 +1,10 @@
 package clause
 
-import "go.mongodb.org/mongo-driver/v2/mongo/options"
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
 
 // Count counts the number of documents in the collection
 // With condition is a map[string]interface{} or bson.M take from Where method
@@ -8,27 +12,50 @@ import "go.mongodb.org/mongo-driver/v2/mongo/options"
 // Warning:
 // - Operation will run EstimatedDocumentCount if condition is nil
 func (c *Clause) MCount() (int64, error) {
+	opts := c.countOptions()
+
+	op := &Operation{Kind: KindRead, Name: "countDocuments", Filter: c.condition, Options: opts}
+	if c.condition == nil {
+		op.Name = "estimatedDocumentCount"
+	}
+
+	err := c.run(op, func(ctx context.Context, op *Operation) error {
+		var res int64
+		var err error
+		if op.Filter == nil {
+			res, err = op.Collection.EstimatedDocumentCount(ctx)
+		} else {
+			res, err = op.Collection.CountDocuments(ctx, op.Filter, optionsAs(op, opts))
+		}
+		if err != nil {
+			return err
+		}
+		op.Result = res
+		return nil
+	}, nil)
+
+	if err != nil {
+		return 0, err
+	}
+	res, _ := resultAs[int64](op)
+	return res, nil
+}
+
+func (c *Clause) countOptions() *options.CountOptionsBuilder {
+	if c.prepared != nil {
+		return c.prepared.countOpts
+	}
 	var opts *options.CountOptionsBuilder = options.Count()
 	if c.opts != nil {
 		opts = c.opts.ToCount()
 	}
-
-	var res int64
-	var err error
-	if c.condition == nil {
-		res, err = c.collection.EstimatedDocumentCount(c.ctx)
-	} else {
+	if c.condition != nil {
 		if c.offset > 0 {
 			opts = options.Count().SetSkip(int64(c.offset))
 		}
 		if c.limit > 0 {
 			opts = options.Count().SetLimit(int64(c.limit))
 		}
-		res, err = c.collection.CountDocuments(c.ctx, c.condition, opts)
 	}
-
-	if err != nil {
-		return 0, err
-	}
-	return res, nil
+	return opts
 }
@@ -1,7 +1,10 @@
 package clause
 
 import (
+	"context"
+
 	"github.com/nghialthanh/morn-go/utils"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
@@ -15,6 +18,11 @@ func (c *Clause) MCreateOne(entity interface{}) (interface{}, error) {
 		opts = c.opts.ToInsertOne()
 	}
 
+	ctx := c.hookContext()
+	if err := callHook(entity, func(h BeforeCreator) error { return h.BeforeCreate(ctx) }); err != nil {
+		return nil, err
+	}
+
 	createField := ""
 	if c.option.CreateAtField != "" {
 		createField = c.option.CreateAtField
@@ -25,13 +33,28 @@ func (c *Clause) MCreateOne(entity interface{}) (interface{}, error) {
 		return nil, err
 	}
 
-	res, err := c.collection.InsertOne(c.ctx, obj, opts)
+	op := &Operation{Kind: KindWrite, Name: "insertOne", Documents: []interface{}{obj}, Options: opts}
+	err = c.run(op, func(ctx context.Context, op *Operation) error {
+		res, err := op.Collection.InsertOne(ctx, op.Documents[0], optionsAs(op, opts))
+		if err != nil {
+			return err
+		}
+		op.Result = res
+		return nil
+	}, nil)
 
 	if err != nil {
 		return nil, err
 	}
+	var insertedID interface{}
+	if res, ok := resultAs[*mongo.InsertOneResult](op); ok {
+		insertedID = res.InsertedID
+	}
+	if err := callHook(entity, func(h AfterCreator) error { return h.AfterCreate(ctx) }); err != nil {
+		return insertedID, err
+	}
 
-	return res.InsertedID, nil
+	return insertedID, nil
 }
 
 // CreateMany insert many object into db
@@ -44,6 +67,11 @@ func (c *Clause) MCreateMany(entityList interface{}) ([]interface{}, error) {
 		opts = c.opts.ToInsertMany()
 	}
 
+	ctx := c.hookContext()
+	if err := callHook(entityList, func(h BeforeCreator) error { return h.BeforeCreate(ctx) }); err != nil {
+		return nil, err
+	}
+
 	list, err := utils.ConvSlice(entityList)
 	if err != nil {
 		return nil, err
@@ -64,10 +92,25 @@ func (c *Clause) MCreateMany(entityList interface{}) ([]interface{}, error) {
 		objList = append(objList, obj)
 	}
 
-	res, err := c.collection.InsertMany(c.ctx, objList, opts)
+	op := &Operation{Kind: KindWrite, Name: "insertMany", Documents: objList, Options: opts}
+	err = c.run(op, func(ctx context.Context, op *Operation) error {
+		res, err := op.Collection.InsertMany(ctx, op.Documents, optionsAs(op, opts))
+		if err != nil {
+			return err
+		}
+		op.Result = res
+		return nil
+	}, nil)
 	if err != nil {
 		return nil, err
 	}
+	var insertedIDs []interface{}
+	if res, ok := resultAs[*mongo.InsertManyResult](op); ok {
+		insertedIDs = res.InsertedIDs
+	}
+	if err := callHook(entityList, func(h AfterCreator) error { return h.AfterCreate(ctx) }); err != nil {
+		return insertedIDs, err
+	}
 
-	return res.InsertedIDs, nil
+	return insertedIDs, nil
 }
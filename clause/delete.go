@@ -1,27 +1,53 @@
 package clause
 
-import "go.mongodb.org/mongo-driver/v2/mongo/options"
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
 
 // Delete all documents mapping with condition in the collection
 // With condition is a map[string]interface{} or bson.M take from Where method
 // Warning:
 // - Operation will delete all documents if condition is nil
+// The BeforeDelete and AfterDelete hooks of the entity set with Model run around it
 func (c *Clause) MDelete() error {
 	var opts *options.DeleteOneOptionsBuilder = options.DeleteOne()
 	if c.opts != nil {
 		opts = c.opts.ToDeleteOne()
 	}
 
-	res, err := c.collection.DeleteOne(c.ctx, c.condition, opts)
-	if err != nil {
+	ctx := c.hookContext()
+	if err := callHook(c.model, func(h BeforeDeleter) error { return h.BeforeDelete(ctx) }); err != nil {
 		return err
 	}
 
-	if res.DeletedCount == 0 {
-		c.logger.Warn("No document deleted")
-	}
+	op := &Operation{Kind: KindWrite, Name: "deleteOne", Filter: c.condition, Options: opts}
+	err := c.run(op, func(ctx context.Context, op *Operation) error {
+		var (
+			res *mongo.DeleteResult
+			err error
+		)
+		if op.capture {
+			res, err = op.captureDelete(ctx)
+		} else {
+			res, err = op.Collection.DeleteOne(ctx, op.Filter, optionsAs(op, opts))
+		}
+		if err != nil {
+			return err
+		}
 
-	return nil
+		if res.DeletedCount == 0 {
+			c.logger.Warn("No document deleted")
+		}
+		op.Result = res
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return callHook(c.model, func(h AfterDeleter) error { return h.AfterDelete(ctx) })
 }
 
 func (c *Clause) MDeleteMany() (int64, error) {
@@ -30,14 +56,34 @@ func (c *Clause) MDeleteMany() (int64, error) {
 		opts = c.opts.ToDeleteMany()
 	}
 
-	res, err := c.collection.DeleteMany(c.ctx, c.condition, opts)
-	if err != nil {
+	ctx := c.hookContext()
+	if err := callHook(c.model, func(h BeforeDeleter) error { return h.BeforeDelete(ctx) }); err != nil {
 		return 0, err
 	}
 
-	if res.DeletedCount == 0 {
-		c.logger.Warn("No document deleted")
+	op := &Operation{Kind: KindWrite, Name: "deleteMany", Filter: c.condition, Options: opts}
+	err := c.run(op, func(ctx context.Context, op *Operation) error {
+		res, err := op.Collection.DeleteMany(ctx, op.Filter, optionsAs(op, opts))
+		if err != nil {
+			return err
+		}
+
+		if res.DeletedCount == 0 {
+			c.logger.Warn("No document deleted")
+		}
+		op.Result = res
+		return nil
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	if res, ok := resultAs[*mongo.DeleteResult](op); ok {
+		deleted = res.DeletedCount
+	}
+	if err := callHook(c.model, func(h AfterDeleter) error { return h.AfterDelete(ctx) }); err != nil {
+		return deleted, err
 	}
 
-	return res.DeletedCount, nil
+	return deleted, nil
 }
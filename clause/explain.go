@@ -0,0 +1,308 @@
+package clause
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ExplainVerbosity is the verbosity of the explain command
+type ExplainVerbosity string
+
+const (
+	QueryPlanner      ExplainVerbosity = "queryPlanner"
+	ExecutionStats    ExplainVerbosity = "executionStats"
+	AllPlansExecution ExplainVerbosity = "allPlansExecution"
+)
+
+// Explainer explains the operations of a clause instead of running them, see Clause.Explain
+type Explainer struct {
+	clause    *Clause
+	ctx       context.Context
+	verbosity ExplainVerbosity
+}
+
+// ExplainResult is the parsed output of the explain command
+// The execution fields are only set with the ExecutionStats and AllPlansExecution verbosities.
+type ExplainResult struct {
+	Plan          *PlanStage
+	Indexes       []string // indexes scanned by the winning plan
+	KeysExamined  int64
+	DocsExamined  int64
+	Returned      int64
+	ExecutionTime time.Duration
+	CollScan      bool // a stage of the winning plan scans the whole collection
+	InMemorySort  bool // the documents are sorted in memory instead of read in index order
+	Raw           bson.M
+}
+
+// PlanStage is a stage of a winning plan, Inputs are the stages it reads from
+type PlanStage struct {
+	Stage  string
+	Index  string
+	Inputs []*PlanStage
+}
+
+// Explain returns an Explainer running explain with verbosity on the operations of the clause
+// The command is rendered like the real operation, see DryRun, so the filter, sort, limit, scope
+// and QueryOption are the same. Explain is not allowed inside transactions. Before hooks run.
+// Example:
+//
+//	res, err := dao.Clause().Where(bson.M{"user_id": 1}).Sort("created_at:desc").Explain(ctx, clause.ExecutionStats).Find()
+//	if res.CollScan {
+//		t.Errorf("query is not index-backed: %s", res.Plan)
+//	}
+func (c *Clause) Explain(ctx context.Context, verbosity ExplainVerbosity) *Explainer {
+	if ctx == nil {
+		ctx = c.hookContext()
+	}
+	if verbosity == "" {
+		verbosity = QueryPlanner
+	}
+	return &Explainer{clause: c, ctx: ctx, verbosity: verbosity}
+}
+
+func (e *Explainer) Find() (*ExplainResult, error) {
+	return e.explain(func(c *Clause) error {
+		_, err := c.FindMany()
+		return err
+	})
+}
+
+func (e *Explainer) FindOne() (*ExplainResult, error) {
+	return e.explain(func(c *Clause) error {
+		_, err := c.FindOne()
+		return err
+	})
+}
+
+func (e *Explainer) Count() (*ExplainResult, error) {
+	return e.explain(func(c *Clause) error {
+		_, err := c.MCount()
+		return err
+	})
+}
+
+func (e *Explainer) Aggregate(pipeline []bson.M) (*ExplainResult, error) {
+	return e.explain(func(c *Clause) error {
+		_, err := c.Aggregate(pipeline)
+		return err
+	})
+}
+
+// UpdateOne explains MUpdateOne, explain never modifies documents
+func (e *Explainer) UpdateOne(updater interface{}) (*ExplainResult, error) {
+	return e.explain(func(c *Clause) error {
+		return c.MUpdateOne(updater)
+	})
+}
+
+func (e *Explainer) UpdateMany(updater interface{}) (*ExplainResult, error) {
+	return e.explain(func(c *Clause) error {
+		_, err := c.UpdateMany(updater)
+		return err
+	})
+}
+
+// DeleteOne explains MDelete, explain never deletes documents
+func (e *Explainer) DeleteOne() (*ExplainResult, error) {
+	return e.explain(func(c *Clause) error {
+		return c.MDelete()
+	})
+}
+
+func (e *Explainer) DeleteMany() (*ExplainResult, error) {
+	return e.explain(func(c *Clause) error {
+		_, err := c.MDeleteMany()
+		return err
+	})
+}
+
+// explain renders the operation run by terminal and explains its command
+func (e *Explainer) explain(terminal func(c *Clause) error) (*ExplainResult, error) {
+	c := e.clause
+	dryRun := c.dryRun
+	c.dryRun = true
+	err := terminal(c)
+	c.dryRun = dryRun
+
+	var statement *Statement
+	if !errors.As(err, &statement) {
+		if err == nil {
+			err = errors.New("explain: the operation was not rendered")
+		}
+		return nil, err
+	}
+	if statement.collection == nil {
+		return nil, fmt.Errorf("explain %s: collection not connected", statement.Operation)
+	}
+
+	var raw bson.M
+	err = statement.collection.Database().RunCommand(e.ctx, bson.D{
+		{Key: "explain", Value: statement.Command},
+		{Key: "verbosity", Value: string(e.verbosity)},
+	}).Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+	return ParseExplain(raw), nil
+}
+
+// ParseExplain reads the winning plan and the execution stats of the output of explain
+// It supports find, count, update, delete and aggregate commands, classic and slot based engine plans,
+// and sharded clusters, where the plans of the shards are the inputs of a SHARD_MERGE stage.
+func ParseExplain(raw bson.M) *ExplainResult {
+	res := &ExplainResult{Raw: raw}
+	explain := raw
+	// the plan of an aggregation that starts with a query is nested in its first stage
+	if stages, ok := raw["stages"].(bson.A); ok {
+		for i, stage := range stages {
+			doc, ok := explainDocument(stage)
+			if !ok {
+				continue
+			}
+			if cursor, ok := explainDocument(doc["$cursor"]); i == 0 && ok {
+				explain = cursor
+			}
+			if _, ok := doc["$sort"]; ok {
+				res.InMemorySort = true
+			}
+		}
+	}
+
+	if planner, ok := explainDocument(explain["queryPlanner"]); ok {
+		if winning, ok := explainDocument(planner["winningPlan"]); ok {
+			res.Plan = parsePlanStage(winning)
+		}
+	}
+	if stats, ok := explainDocument(explain["executionStats"]); ok {
+		res.KeysExamined = explainInt(stats["totalKeysExamined"])
+		res.DocsExamined = explainInt(stats["totalDocsExamined"])
+		res.Returned = explainInt(stats["nReturned"])
+		res.ExecutionTime = time.Duration(explainInt(stats["executionTimeMillis"])) * time.Millisecond
+	}
+
+	res.Plan.walk(func(stage *PlanStage) {
+		switch stage.Stage {
+		case "COLLSCAN":
+			res.CollScan = true
+		case "SORT":
+			res.InMemorySort = true
+		}
+		if stage.Index != "" {
+			res.Indexes = append(res.Indexes, stage.Index)
+		}
+	})
+	return res
+}
+
+func parsePlanStage(plan bson.M) *PlanStage {
+	// slot based engine plans are wrapped in queryPlan
+	if queryPlan, ok := explainDocument(plan["queryPlan"]); ok {
+		plan = queryPlan
+	}
+	if shards, ok := plan["shards"].(bson.A); ok {
+		stage := &PlanStage{Stage: "SHARD_MERGE"}
+		for _, shard := range shards {
+			doc, _ := explainDocument(shard)
+			if winning, ok := explainDocument(doc["winningPlan"]); ok {
+				stage.Inputs = append(stage.Inputs, parsePlanStage(winning))
+			}
+		}
+		return stage
+	}
+
+	stage := &PlanStage{}
+	stage.Stage, _ = plan["stage"].(string)
+	stage.Index, _ = plan["indexName"].(string)
+	if stage.Index == "" && stage.Stage == "IDHACK" {
+		stage.Index = "_id_"
+	}
+	if input, ok := explainDocument(plan["inputStage"]); ok {
+		stage.Inputs = append(stage.Inputs, parsePlanStage(input))
+	}
+	if inputs, ok := plan["inputStages"].(bson.A); ok {
+		for _, item := range inputs {
+			if input, ok := explainDocument(item); ok {
+				stage.Inputs = append(stage.Inputs, parsePlanStage(input))
+			}
+		}
+	}
+	return stage
+}
+
+// walk calls fn on the stage and the stages it reads from
+func (s *PlanStage) walk(fn func(stage *PlanStage)) {
+	if s == nil {
+		return
+	}
+	fn(s)
+	for _, input := range s.Inputs {
+		input.walk(fn)
+	}
+}
+
+// String renders the plan from its root, for example "FETCH > IXSCAN user_id_1"
+func (s *PlanStage) String() string {
+	if s == nil {
+		return ""
+	}
+	stage := s.Stage
+	if s.Index != "" {
+		stage += " " + s.Index
+	}
+	switch len(s.Inputs) {
+	case 0:
+		return stage
+	case 1:
+		return stage + " > " + s.Inputs[0].String()
+	}
+	inputs := make([]string, 0, len(s.Inputs))
+	for _, input := range s.Inputs {
+		inputs = append(inputs, input.String())
+	}
+	return stage + " > (" + strings.Join(inputs, ", ") + ")"
+}
+
+// IndexBacked reports whether the winning plan reads through an index without scanning the collection
+func (r *ExplainResult) IndexBacked() bool {
+	return !r.CollScan && len(r.Indexes) > 0
+}
+
+// DocsExaminedRatio is the number of documents examined per document returned, a high ratio points to a missing index
+func (r *ExplainResult) DocsExaminedRatio() float64 {
+	if r.Returned == 0 {
+		return float64(r.DocsExamined)
+	}
+	return float64(r.DocsExamined) / float64(r.Returned)
+}
+
+func explainDocument(value interface{}) (bson.M, bool) {
+	switch doc := value.(type) {
+	case bson.M:
+		return doc, true
+	case bson.D:
+		result := make(bson.M, len(doc))
+		for _, e := range doc {
+			result[e.Key] = e.Value
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+func explainInt(value interface{}) int64 {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
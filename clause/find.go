@@ -1,6 +1,8 @@
 package clause
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
@@ -9,73 +11,120 @@ import (
 
 // FindOne finds a single document in the collection
 // With condition is a map[string]interface{} or bson.M take from Where method
+// The Sort of the clause picks the document returned when several match
 // Warning:
 // - entity must be a pointer to a struct
 func (c *Clause) MFindOne(entity interface{}) error {
-	var opts *options.FindOneOptionsBuilder = options.FindOne()
-	if c.opts != nil {
-		opts = c.opts.ToFindOne()
-	}
-
-	res := c.collection.FindOne(c.ctx, c.condition, opts)
-	if res == nil || res.Err() != nil {
-		return res.Err()
-	}
-
-	err := c.convResultToObj(entity, res)
+	opts := c.findOneOptions()
+
+	op := &Operation{Kind: KindRead, Name: "findOne", Filter: c.condition, Options: opts}
+	err := c.run(op, c.findOne(opts), func(ctx context.Context, op *Operation) error {
+		if _, ok := resultAs[*mongo.SingleResult](op); !ok {
+			return ErrNoResult
+		}
+		return c.decodeInto(ctx, op, entity)
+	})
 	if err != nil {
 		return err
 	}
-	return nil
+	ctx := c.hookContext()
+	return callHook(entity, func(h AfterFinder) error { return h.AfterFind(ctx) })
 }
 
 func (c *Clause) FindOne() (*mongo.SingleResult, error) {
-	var opts *options.FindOneOptionsBuilder = options.FindOne()
-	if c.opts != nil {
-		opts = c.opts.ToFindOne()
-	}
+	opts := c.findOneOptions()
 
-	res := c.collection.FindOne(c.ctx, c.condition, opts)
-	if res == nil || res.Err() != nil {
-		return nil, res.Err()
+	op := &Operation{Kind: KindRead, Name: "findOne", Filter: c.condition, Options: opts}
+	err := c.run(op, c.findOne(opts), nil)
+	if err != nil {
+		return nil, err
 	}
 
+	res, ok := resultAs[*mongo.SingleResult](op)
+	if !ok {
+		return nil, ErrNoResult
+	}
 	return res, nil
 }
 
+func (c *Clause) findOne(opts *options.FindOneOptionsBuilder) Invoker {
+	return func(ctx context.Context, op *Operation) error {
+		res := op.Collection.FindOne(ctx, op.Filter, optionsAs(op, opts))
+		if err := res.Err(); err != nil {
+			return err
+		}
+		op.Result = res
+		return nil
+	}
+}
+
 // FindMany finds multiple documents in the collection
 // With condition is a map[string]interface{} or bson.M take from Where method
 // Warning:
 // - entity must be a pointer to a slice of struct
 func (c *Clause) MFindMany(entity interface{}) error {
-	var opts *options.FindOptionsBuilder = options.Find()
-	if c.opts != nil {
-		opts = c.opts.ToFind()
+	opts := c.findOptions()
+
+	op := &Operation{Kind: KindRead, Name: "find", Filter: c.condition, Options: opts}
+	err := c.run(op, c.find(opts), func(ctx context.Context, op *Operation) error {
+		if _, ok := resultAs[*mongo.Cursor](op); !ok {
+			return ErrNoResult
+		}
+		return c.decodeInto(ctx, op, entity)
+	})
+	if err != nil {
+		return err
 	}
+	ctx := c.hookContext()
+	return callHook(entity, func(h AfterFinder) error { return h.AfterFind(ctx) })
+}
 
-	if c.offset > 0 {
-		opts = opts.SetSkip(int64(c.offset))
+func (c *Clause) FindMany() (*mongo.Cursor, error) {
+	opts := c.findOptions()
+
+	op := &Operation{Kind: KindRead, Name: "find", Filter: c.condition, Options: opts}
+	err := c.run(op, c.find(opts), nil)
+	if err != nil {
+		return nil, err
 	}
-	if c.limit > 0 {
-		opts = opts.SetLimit(int64(c.limit))
+
+	res, ok := resultAs[*mongo.Cursor](op)
+	if !ok {
+		return nil, ErrNoResult
 	}
-	if c.sort != nil {
-		opts = opts.SetSort(c.sort)
+	return res, nil
+}
+
+func (c *Clause) find(opts *options.FindOptionsBuilder) Invoker {
+	return func(ctx context.Context, op *Operation) error {
+		res, err := op.Collection.Find(ctx, op.Filter, optionsAs(op, opts))
+		if err != nil {
+			return err
+		}
+		op.Result = res
+		return nil
 	}
+}
 
-	res, err := c.collection.Find(c.ctx, c.condition, opts)
-	if err != nil {
-		return err
+func (c *Clause) findOneOptions() *options.FindOneOptionsBuilder {
+	if c.prepared != nil {
+		return c.prepared.findOneOpts
+	}
+	var opts *options.FindOneOptionsBuilder = options.FindOne()
+	if c.opts != nil {
+		opts = c.opts.ToFindOne()
 	}
 
-	err = c.convResultToObj(entity, res)
-	if err != nil {
-		return err
+	if c.sort != nil {
+		opts = opts.SetSort(c.sort)
 	}
-	return nil
+	return opts
 }
 
-func (c *Clause) FindMany() (*mongo.Cursor, error) {
+func (c *Clause) findOptions() *options.FindOptionsBuilder {
+	if c.prepared != nil {
+		return c.prepared.findOpts
+	}
 	var opts *options.FindOptionsBuilder = options.Find()
 	if c.opts != nil {
 		opts = c.opts.ToFind()
@@ -90,11 +139,5 @@ func (c *Clause) FindMany() (*mongo.Cursor, error) {
 	if c.sort != nil {
 		opts = opts.SetSort(c.sort)
 	}
-
-	res, err := c.collection.Find(c.ctx, c.condition, opts)
-	if err != nil {
-		return nil, err
-	}
-
-	return res, nil
+	return opts
 }
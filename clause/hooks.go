@@ -0,0 +1,80 @@
+package clause
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Lifecycle hooks implemented by entities
+// They receive the context of the clause, which is bound to the session inside Dao.Session.
+// An error returned by a Before hook aborts the operation before it reaches the database,
+// an error returned by an After hook is returned by the terminal method once the operation is done.
+// Hooks with a pointer receiver only run when a pointer is passed.
+type (
+	BeforeCreator interface {
+		BeforeCreate(ctx context.Context) error
+	}
+	AfterCreator interface {
+		AfterCreate(ctx context.Context) error
+	}
+	BeforeUpdater interface {
+		BeforeUpdate(ctx context.Context) error
+	}
+	AfterUpdater interface {
+		AfterUpdate(ctx context.Context) error
+	}
+	AfterFinder interface {
+		AfterFind(ctx context.Context) error
+	}
+	BeforeDeleter interface {
+		BeforeDelete(ctx context.Context) error
+	}
+	AfterDeleter interface {
+		AfterDelete(ctx context.Context) error
+	}
+)
+
+// Model sets the entity MDelete and MDeleteMany are about, its BeforeDelete and AfterDelete hooks run around them
+func (c *Clause) Model(entity interface{}) *Clause {
+	c.model = entity
+	return c
+}
+
+func (c *Clause) hookContext() context.Context {
+	if c.ctx == nil {
+		return context.TODO()
+	}
+	return c.ctx
+}
+
+// callHook calls fn on entity, or on every element when entity is a slice or a pointer to a slice,
+// if it implements the hook interface H
+func callHook[H any](entity interface{}, fn func(hook H) error) error {
+	value := reflect.ValueOf(entity)
+	if !value.IsValid() {
+		return nil
+	}
+	if value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Kind() == reflect.Slice {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice {
+		if hook, ok := entity.(H); ok {
+			return fn(hook)
+		}
+		return nil
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		elem := value.Index(i)
+		if elem.Kind() != reflect.Ptr && elem.Kind() != reflect.Interface && elem.CanAddr() {
+			elem = elem.Addr()
+		}
+		if hook, ok := elem.Interface().(H); ok {
+			if err := fn(hook); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
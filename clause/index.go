@@ -1,6 +1,9 @@
 package clause
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/nghialthanh/morn-go/utils"
@@ -25,12 +28,69 @@ func (c *Clause) CreateIndex(index ...string) error {
 		indexList = append(indexList, bson.E{Key: key, Value: intValue})
 	}
 
-	_, err := c.collection.Indexes().CreateOne(c.ctx, mongo.IndexModel{
+	_, err := c.CreateIndexModel(mongo.IndexModel{
 		Keys:    indexList,
 		Options: opts,
 	})
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrDryRun) {
 		c.logger.Error("Failed to create index", err)
 	}
 	return err
 }
+
+// CreateIndexModel creates the index of model and returns its name
+// Unlike CreateIndex the keys may be of any type, for example "text" or "2dsphere", and the error is not logged.
+func (c *Clause) CreateIndexModel(model mongo.IndexModel) (string, error) {
+	op := &Operation{Kind: KindIndex, Name: "createIndexes", Documents: []interface{}{model}, Options: model.Options}
+	var name string
+	err := c.run(op, func(ctx context.Context, op *Operation) error {
+		model, ok := op.Documents[0].(mongo.IndexModel)
+		if !ok {
+			return fmt.Errorf("createIndexes: expected a mongo.IndexModel, got %T", op.Documents[0])
+		}
+		name, err := op.Collection.Indexes().CreateOne(ctx, model)
+		if err != nil {
+			return err
+		}
+		op.Result = name
+		return nil
+	}, func(ctx context.Context, op *Operation) error {
+		name, _ = resultAs[string](op)
+		return nil
+	})
+	return name, err
+}
+
+// DropIndex drops the index named name
+func (c *Clause) DropIndex(name string) error {
+	op := &Operation{Kind: KindIndex, Name: "dropIndexes", Documents: []interface{}{name}}
+	return c.run(op, func(ctx context.Context, op *Operation) error {
+		name, ok := op.Documents[0].(string)
+		if !ok {
+			return fmt.Errorf("dropIndexes: expected an index name, got %T", op.Documents[0])
+		}
+		return op.Collection.Indexes().DropOne(ctx, name)
+	}, nil)
+}
+
+// ListIndexes returns the specifications of the indexes of the collection, as listIndexes returns them
+// It runs on the collection of the index route, like CreateIndex, and is allowed in read-only contexts.
+func (c *Clause) ListIndexes() ([]bson.D, error) {
+	op := &Operation{Kind: KindIndex, Name: "listIndexes"}
+	var indexes []bson.D
+	err := c.run(op, func(ctx context.Context, op *Operation) error {
+		cursor, err := op.Collection.Indexes().List(ctx)
+		if err != nil {
+			return err
+		}
+		op.Result = cursor
+		return nil
+	}, func(ctx context.Context, op *Operation) error {
+		cursor, ok := resultAs[*mongo.Cursor](op)
+		if !ok {
+			return ErrNoResult
+		}
+		return cursor.All(ctx, &indexes)
+	})
+	return indexes, err
+}
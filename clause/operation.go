@@ -0,0 +1,335 @@
+package clause
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/topology"
+)
+
+var ErrReadOnly = errors.New("write operation rejected: context is read-only")
+
+// OpKind classifies an operation so that policies can treat reads and writes differently
+type OpKind int
+
+const (
+	KindRead OpKind = iota
+	KindWrite
+	KindAggregate
+	KindIndex
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case KindRead:
+		return "read"
+	case KindWrite:
+		return "write"
+	case KindAggregate:
+		return "aggregate"
+	case KindIndex:
+		return "index"
+	}
+	return "unknown"
+}
+
+// Gate admits operations before they reach the driver
+// Admit returns an error to reject the operation, otherwise release is called with the result once it finished
+type Gate interface {
+	Admit(ctx context.Context, kind OpKind) (release func(err error), err error)
+}
+
+// Operation describes one call to the driver made by a terminal method
+// Interceptors receive it before the call and may change its fields, Result holds what the driver returned:
+//   - *mongo.SingleResult for findOne and findOneAndUpdate
+//   - *mongo.Cursor for find and aggregate
+//   - int64 for countDocuments and estimatedDocumentCount
+//   - *mongo.InsertOneResult, *mongo.InsertManyResult, *mongo.UpdateResult or *mongo.DeleteResult for writes
+//   - *mongo.BulkWriteResult for bulkWrite, whose writes are Models
+//   - string, the index name, for createIndexes
+//   - *mongo.Cursor for listIndexes, dropIndexes has no result
+//
+// Collection is the first collection of the route, setting another one runs the operation on it only.
+type Operation struct {
+	Collection *mongo.Collection
+	Kind       OpKind
+	Name       string
+	Filter     interface{}
+	Update     interface{}
+	Documents  []interface{}
+	Pipeline   []bson.M
+	Models     []mongo.WriteModel
+	Options    interface{}
+	Result     interface{}
+	// Before is the document changed by the write as it was before it, only set once Capture was called
+	Before bson.Raw
+
+	capture  bool
+	returned int64
+	counted  bool
+	decoded  []func(returned int64, err error)
+}
+
+// Invoker runs an Operation, it is the next step of an interceptor chain
+type Invoker func(ctx context.Context, op *Operation) error
+
+// Interceptor wraps every Operation of a clause
+// Interceptors run once the gates of the clause admitted the Operation, rejected ones never reach them (see Clause.OnReject).
+// It may change op or ctx before calling next, inspect op.Result and the error after it, or
+// complete the Operation without calling next by setting op.Result, or by returning an error to reject it.
+// Example:
+//
+//	func audit(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+//		err := next(ctx, op)
+//		log.Printf("%s %s: %v", op.Collection.Name(), op.Name, err)
+//		return err
+//	}
+type Interceptor func(ctx context.Context, op *Operation, next Invoker) error
+
+// ErrNoResult is returned by a terminal method when an interceptor completed its Operation without a result
+var ErrNoResult = errors.New("operation completed by an interceptor without a result")
+
+// OnDecoded calls f once the terminal method of op decoded its result, with the number of documents
+// decoded and the error of the decoding. f is called for failed operations too, with 0 and a nil error.
+// A result returned to the caller as is, such as the cursor of Find, is not decoded: returned is then 1
+// for a SingleResult holding a document and 0 otherwise. Counts and writes return no document.
+func (op *Operation) OnDecoded(f func(returned int64, err error)) {
+	op.decoded = append(op.decoded, f)
+}
+
+// decodeInto decodes the result of op into entity and records the number of documents decoded
+func (c *Clause) decodeInto(ctx context.Context, op *Operation, entity interface{}) error {
+	if err := c.convResultToObj(ctx, entity, op.Result); err != nil {
+		return err
+	}
+	op.returned, op.counted = 1, true
+	if value := reflect.ValueOf(entity); value.Kind() == reflect.Ptr && value.Elem().Kind() == reflect.Slice {
+		op.returned = int64(value.Elem().Len())
+	}
+	return nil
+}
+
+// returnedDocuments returns the number of documents returned by op when its result is not decoded
+func (op *Operation) returnedDocuments() int64 {
+	if op.counted {
+		return op.returned
+	}
+	if res, ok := op.Result.(*mongo.SingleResult); ok && res.Err() == nil {
+		return 1
+	}
+	return 0
+}
+
+// resultAs returns the result of op as T and false when there is none or it has another type
+func resultAs[T any](op *Operation) (T, bool) {
+	result, ok := op.Result.(T)
+	return result, ok
+}
+
+// optionsAs returns the options of op as T, keeping fallback when an interceptor replaced them with another type
+func optionsAs[T any](op *Operation, fallback T) T {
+	if opts, ok := op.Options.(T); ok {
+		return opts
+	}
+	return fallback
+}
+
+// writes reports whether the operation modifies data
+// Aggregations are writes when their pipeline ends with $out or $merge
+func (op *Operation) writes() bool {
+	switch op.Kind {
+	case KindWrite:
+		return true
+	case KindIndex:
+		return op.Name != "listIndexes"
+	case KindAggregate:
+		if len(op.Pipeline) == 0 {
+			return false
+		}
+		last := op.Pipeline[len(op.Pipeline)-1]
+		_, out := last["$out"]
+		_, merge := last["$merge"]
+		return out || merge
+	}
+	return false
+}
+
+// run executes a terminal method through the policies attached to the clause
+// The gates admit the operation before the interceptors run, the interceptors wrap the retries of call.
+// call makes the driver call and stores its result in op.Result, then, once the interceptors returned,
+// decode reads op.Result. A DryRun clause stops once the scope and the route are applied and returns
+// the Statement of op. Every method that reaches the driver must go through run.
+func (c *Clause) run(op *Operation, call Invoker, decode Invoker) (err error) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+
+	if c.err != nil {
+		return c.err
+	}
+	if op.writes() && IsReadOnly(ctx) {
+		return ErrReadOnly
+	}
+	if timeout := c.operationTimeout(op.Kind); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if c.scope != nil {
+		if err := c.applyScope(op); err != nil {
+			return err
+		}
+	}
+	collections := c.route(op.Kind)
+
+	op.Collection = collections[0]
+	if c.dryRun {
+		statement, err := newStatement(op)
+		if err != nil {
+			return err
+		}
+		c.statement = statement
+		return statement
+	}
+	for _, gate := range c.gates {
+		release, admitErr := gate.Admit(ctx, op.Kind)
+		if admitErr != nil {
+			if c.onReject != nil {
+				c.onReject(ctx, op, admitErr)
+			}
+			return admitErr
+		}
+		defer func() { release(err) }()
+	}
+
+	err = c.intercept(ctx, op, func(ctx context.Context, op *Operation) error {
+		if op.Collection != collections[0] {
+			collections = []*mongo.Collection{op.Collection}
+		}
+		return c.withRetry(ctx, op, func() error {
+			return c.execute(ctx, op, collections, call)
+		})
+	})
+	var (
+		returned  int64
+		decodeErr error
+	)
+	if err == nil {
+		if decode != nil {
+			decodeErr = decode(ctx, op)
+		}
+		returned = op.returnedDocuments()
+	}
+	for _, f := range op.decoded {
+		f(returned, decodeErr)
+	}
+	if err != nil {
+		return err
+	}
+	return decodeErr
+}
+
+// Intercept adds interceptors around every Operation of the clause, the first one is the outermost
+func (c *Clause) Intercept(interceptors ...Interceptor) *Clause {
+	c.interceptors = append(c.interceptors, interceptors...)
+	return c
+}
+
+// intercept runs op through the interceptors of the clause, then invoke
+func (c *Clause) intercept(ctx context.Context, op *Operation, invoke Invoker) error {
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.interceptors[i], invoke
+		invoke = func(ctx context.Context, op *Operation) error {
+			return interceptor(ctx, op, next)
+		}
+	}
+	return invoke(ctx, op)
+}
+
+// execute runs op on the first collection, falling back to the next ones while their deployment is unreachable
+func (c *Clause) execute(ctx context.Context, op *Operation, collections []*mongo.Collection, call Invoker) (err error) {
+	for n, collection := range collections {
+		op.Collection = collection
+		if c.collOpts != nil && !inTransaction(ctx) {
+			op.Collection = collection.Clone(c.collOpts)
+		}
+		op.Result = nil
+		err = call(ctx, op)
+		if n == len(collections)-1 || op.writes() || mongo.SessionFromContext(ctx) != nil || !unavailable(err) {
+			return err
+		}
+		c.logger.Warnf("%s on %s failed, falling back to the next deployment: %v", op.Name, collection.Name(), err)
+	}
+	return err
+}
+
+// operationTimeout returns the timeout of the operations of kind, zero meaning no timeout
+func (c *Clause) operationTimeout(kind OpKind) time.Duration {
+	if c.timeout != nil {
+		return *c.timeout
+	}
+	switch kind {
+	case KindRead:
+		return c.option.ReadTimeout
+	case KindWrite:
+		return c.option.WriteTimeout
+	case KindAggregate:
+		return c.option.AggregateTimeout
+	case KindIndex:
+		return c.option.IndexTimeout
+	}
+	return 0
+}
+
+// route returns the collections an operation of kind runs on, in order of preference
+func (c *Clause) route(kind OpKind) []*mongo.Collection {
+	if collections, ok := c.routes[kind]; ok {
+		return collections
+	}
+	if collections, ok := c.routes[KindWrite]; ok && kind == KindIndex {
+		return collections
+	}
+	return []*mongo.Collection{c.collection}
+}
+
+// unavailable reports whether err means the deployment could not be reached
+func unavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, mongo.ErrClientDisconnected) ||
+		errors.As(err, &topology.ServerSelectionError{}) ||
+		mongo.IsNetworkError(err)
+}
+
+// inTransaction reports whether ctx is bound to a session with a running transaction, see Transaction
+func inTransaction(ctx context.Context) bool {
+	session := mongo.SessionFromContext(ctx)
+	marked, _ := ctx.Value(transactionKey{}).(*mongo.Session)
+	return session != nil && session == marked
+}
+
+type transactionKey struct{}
+
+// Transaction returns a context marking that the session bound to ctx runs a transaction
+// Dao.Session sets it, a context bound to a transaction started otherwise must be marked by the caller.
+func Transaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transactionKey{}, mongo.SessionFromContext(ctx))
+}
+
+type readOnlyKey struct{}
+
+// ReadOnly returns a context in which every write operation fails with ErrReadOnly
+func ReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+func IsReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
@@ -0,0 +1,318 @@
+package clause
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrInvalidParams = errors.New("invalid parameters")
+
+// Placeholder is a named parameter of a prepared filter, see Param
+type Placeholder struct {
+	name string
+	typ  reflect.Type
+}
+
+// Param returns a placeholder for the parameter name, bound to a value of type T by Prepared.Bind
+// Use Param[any] to accept any value.
+// Example:
+//
+//	p, err := dao.Clause().Where(bson.M{"user_id": clause.Param[int64]("id")}).Sort("created_at:desc").Prepare()
+//	c, err := p.Bind(clause.Params{"id": int64(42)})
+//	err = c.MFindMany(&users)
+func Param[T any](name string) Placeholder {
+	return Placeholder{name: name, typ: reflect.TypeOf((*T)(nil)).Elem()}
+}
+
+func (p Placeholder) Name() string {
+	return p.name
+}
+
+// MarshalBSONValue fails, a placeholder only reaches the driver when the clause was not prepared
+func (p Placeholder) MarshalBSONValue() (byte, []byte, error) {
+	return 0, nil, fmt.Errorf("parameter %q is not bound, call Prepare and Bind", p.name)
+}
+
+// Params are the values of the parameters of a Prepared query, by name
+type Params map[string]interface{}
+
+// Prepared is a compiled query, immutable and safe for concurrent use
+// The filter is copied when the query is prepared, so that changing the documents given to Where has no effect,
+// and kept as a skeleton where only the documents holding placeholders are copied on Bind. The options of
+// find, findOne and count are rendered once, with the sort, skip and limit of the clause.
+type Prepared struct {
+	template *Clause
+	filter   interface{}
+	params   map[string]reflect.Type
+
+	findOpts    *options.FindOptionsBuilder
+	findOneOpts *options.FindOneOptionsBuilder
+	countOpts   *options.CountOptionsBuilder
+}
+
+// Prepare compiles the query of the clause: its filter with placeholders, sort, skip, limit and QueryOption
+// The clause is left unchanged. Placeholders are only supported in the filter.
+// Calling Sort, Skip, Limit, Page or Option on a bound clause drops the compiled options, they are rendered again.
+func (c *Clause) Prepare() (*Prepared, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	condition := copyFilter(c.condition)
+	p := &Prepared{params: make(map[string]reflect.Type)}
+	filter, err := p.compile(condition)
+	if err != nil {
+		return nil, err
+	}
+	p.filter = filter
+
+	template := c.clone()
+	template.condition = condition
+	template.prepared = nil
+	p.findOpts = template.findOptions()
+	p.findOneOpts = template.findOneOptions()
+	p.countOpts = template.countOptions()
+	p.template = template
+	return p, nil
+}
+
+// Params returns the names of the parameters of the query, sorted
+func (p *Prepared) Params() []string {
+	names := make([]string, 0, len(p.params))
+	for name := range p.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bind returns a new clause running the query with params, with the context and policies of the prepared clause
+// Every parameter must be given with its type and no other, otherwise the error wraps ErrInvalidParams.
+func (p *Prepared) Bind(params Params) (*Clause, error) {
+	return p.BindTo(p.template.clone(), params)
+}
+
+// BindTo sets the query with params on c, for example dao.Ctx(ctx) to run it with the context of a request
+func (p *Prepared) BindTo(c *Clause, params Params) (*Clause, error) {
+	if err := p.validate(params); err != nil {
+		return nil, err
+	}
+	c.condition = bind(p.filter, params)
+	c.sort = p.template.sort
+	c.offset = p.template.offset
+	c.limit = p.template.limit
+	c.opts = p.template.opts
+	c.prepared = p
+	return c, nil
+}
+
+func (p *Prepared) validate(params Params) error {
+	for name, typ := range p.params {
+		value, ok := params[name]
+		if !ok {
+			return fmt.Errorf("%w: missing parameter %q", ErrInvalidParams, name)
+		}
+		if value == nil {
+			switch typ.Kind() {
+			case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+				continue
+			}
+			return fmt.Errorf("%w: parameter %q: expected %s, got nil", ErrInvalidParams, name, typ)
+		}
+		if !reflect.TypeOf(value).AssignableTo(typ) {
+			return fmt.Errorf("%w: parameter %q: expected %s, got %T", ErrInvalidParams, name, typ, value)
+		}
+	}
+	if len(params) > len(p.params) {
+		for name := range params {
+			if _, ok := p.params[name]; !ok {
+				return fmt.Errorf("%w: unknown parameter %q", ErrInvalidParams, name)
+			}
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of c whose slices, maps and builders can be changed without affecting c
+func (c *Clause) clone() *Clause {
+	cp := *c
+	cp.gates = slices.Clip(c.gates)
+	cp.interceptors = slices.Clip(c.interceptors)
+	cp.scope = maps.Clone(c.scope)
+	cp.routes = maps.Clone(c.routes)
+	if c.collOpts != nil {
+		cp.collOpts = &options.CollectionOptionsBuilder{Opts: slices.Clip(c.collOpts.Opts)}
+	}
+	cp.statement = nil
+	return &cp
+}
+
+// copyFilter returns a deep copy of the documents and arrays of filter, other values are kept as they are
+func copyFilter(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.M:
+		doc := make(bson.M, len(v))
+		for key, field := range v {
+			doc[key] = copyFilter(field)
+		}
+		return doc
+	case map[string]interface{}:
+		doc := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			doc[key] = copyFilter(field)
+		}
+		return doc
+	case bson.D:
+		doc := make(bson.D, len(v))
+		for i, e := range v {
+			doc[i] = bson.E{Key: e.Key, Value: copyFilter(e.Value)}
+		}
+		return doc
+	case bson.A:
+		values := make(bson.A, len(v))
+		for i, field := range v {
+			values[i] = copyFilter(field)
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, field := range v {
+			values[i] = copyFilter(field)
+		}
+		return values
+	}
+	return value
+}
+
+// Nodes of a compiled filter, the documents and arrays without placeholders are kept as they are
+type (
+	paramNode struct {
+		name string
+	}
+	documentNode struct {
+		keys   []string
+		values []interface{}
+		// ordered documents are bound to a bson.D, the others to a bson.M
+		ordered bool
+	}
+	arrayNode struct {
+		values []interface{}
+	}
+)
+
+// compile returns the skeleton of value, registering its placeholders
+func (p *Prepared) compile(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case Placeholder:
+		if v.name == "" {
+			return nil, errors.New("prepare: parameter without name")
+		}
+		if typ, ok := p.params[v.name]; ok && typ != v.typ {
+			return nil, fmt.Errorf("prepare: parameter %q declared as %s and %s", v.name, typ, v.typ)
+		}
+		p.params[v.name] = v.typ
+		return paramNode{name: v.name}, nil
+	case bson.M:
+		return p.compileMap(v, v)
+	case map[string]interface{}:
+		return p.compileMap(v, v)
+	case bson.D:
+		node := &documentNode{ordered: true}
+		dynamic := false
+		for _, e := range v {
+			compiled, err := p.compile(e.Value)
+			if err != nil {
+				return nil, err
+			}
+			dynamic = dynamic || isNode(compiled)
+			node.keys = append(node.keys, e.Key)
+			node.values = append(node.values, compiled)
+		}
+		if !dynamic {
+			return v, nil
+		}
+		return node, nil
+	case bson.A:
+		return p.compileArray(v, v)
+	case []interface{}:
+		return p.compileArray(v, v)
+	}
+	return value, nil
+}
+
+func (p *Prepared) compileMap(original interface{}, m map[string]interface{}) (interface{}, error) {
+	node := &documentNode{}
+	dynamic := false
+	for key, value := range m {
+		compiled, err := p.compile(value)
+		if err != nil {
+			return nil, err
+		}
+		dynamic = dynamic || isNode(compiled)
+		node.keys = append(node.keys, key)
+		node.values = append(node.values, compiled)
+	}
+	if !dynamic {
+		return original, nil
+	}
+	return node, nil
+}
+
+func (p *Prepared) compileArray(original interface{}, values []interface{}) (interface{}, error) {
+	node := &arrayNode{}
+	dynamic := false
+	for _, value := range values {
+		compiled, err := p.compile(value)
+		if err != nil {
+			return nil, err
+		}
+		dynamic = dynamic || isNode(compiled)
+		node.values = append(node.values, compiled)
+	}
+	if !dynamic {
+		return original, nil
+	}
+	return node, nil
+}
+
+func isNode(value interface{}) bool {
+	switch value.(type) {
+	case paramNode, *documentNode, *arrayNode:
+		return true
+	}
+	return false
+}
+
+// bind returns the filter of the skeleton node with params
+func bind(node interface{}, params Params) interface{} {
+	switch n := node.(type) {
+	case paramNode:
+		return params[n.name]
+	case *documentNode:
+		if n.ordered {
+			doc := make(bson.D, len(n.keys))
+			for i, key := range n.keys {
+				doc[i] = bson.E{Key: key, Value: bind(n.values[i], params)}
+			}
+			return doc
+		}
+		doc := make(bson.M, len(n.keys))
+		for i, key := range n.keys {
+			doc[key] = bind(n.values[i], params)
+		}
+		return doc
+	case *arrayNode:
+		values := make(bson.A, len(n.values))
+		for i, value := range n.values {
+			values[i] = bind(value, params)
+		}
+		return values
+	}
+	return node
+}
@@ -0,0 +1,186 @@
+package clause
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	defaultRetryAttempts   = 3
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultRetryMaxBackoff = 2 * time.Second
+	defaultRetryMultiplier = 2
+)
+
+// retryableCodes are the server errors returned while a replica set elects a new primary or a node shuts down
+var retryableCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+// idempotentOperators are the update operators giving the same document when applied twice
+var idempotentOperators = map[string]bool{
+	"$set":         true,
+	"$unset":       true,
+	"$setOnInsert": true,
+	"$min":         true,
+	"$max":         true,
+	"$addToSet":    true,
+}
+
+// Retryable is the default classifier of RetryPolicy
+// It accepts network errors, server selection failures, not-primary and shutdown errors
+// and errors labelled RetryableWriteError by the server.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) || (unavailable(err) && !errors.Is(err, mongo.ErrClientDisconnected)) {
+		return true
+	}
+	var labeled mongo.LabeledError
+	if errors.As(err, &labeled) && labeled.HasErrorLabel("RetryableWriteError") {
+		return true
+	}
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		for _, code := range retryableCodes {
+			if serverErr.HasErrorCode(code) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Retry sets the retry policy of the clause, overriding MornOption.Retry, nil disables retries
+func (c *Clause) Retry(policy *option.RetryPolicy) *Clause {
+	c.retry = policy
+	c.retrySet = true
+	return c
+}
+
+// OnRetry sets fn to be called before every retry of an operation of the clause, attempt is the failed one
+func (c *Clause) OnRetry(fn func(ctx context.Context, op *Operation, attempt int, err error)) *Clause {
+	c.onRetry = fn
+	return c
+}
+
+func (c *Clause) retryPolicy() *option.RetryPolicy {
+	if c.retrySet {
+		return c.retry
+	}
+	return c.option.Retry
+}
+
+// withRetry calls attempt until it succeeds, fails with an error the policy does not retry or runs out of attempts
+// Operations in a transaction are never retried, the transaction has to be retried as a whole.
+func (c *Clause) withRetry(ctx context.Context, op *Operation, attempt func() error) error {
+	policy := c.retryPolicy()
+	if policy == nil || inTransaction(ctx) || (!policy.RetryNonIdempotent && !op.idempotent()) {
+		return attempt()
+	}
+
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = defaultRetryAttempts
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = Retryable
+	}
+
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			if n > 1 {
+				c.logger.Infof("%s succeeded after %d attempts", op.Name, n)
+			}
+			return nil
+		}
+		if !retryable(err) {
+			return err
+		}
+		if n >= maxAttempts {
+			if maxAttempts > 1 {
+				c.logger.Errorf("%s failed after %d attempts: %v", op.Name, n, err)
+			}
+			return err
+		}
+
+		delay := backoff(policy, n)
+		c.logger.Warnf("%s failed (attempt %d/%d), retrying in %s: %v", op.Name, n, maxAttempts, delay, err)
+		if c.onRetry != nil {
+			c.onRetry(ctx, op, n, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait after the failed attempt n, between half and all of the exponential delay
+func backoff(policy *option.RetryPolicy, n int) time.Duration {
+	initial, max, multiplier := policy.InitialBackoff, policy.MaxBackoff, policy.Multiplier
+	if initial <= 0 {
+		initial = defaultRetryBackoff
+	}
+	if max <= 0 {
+		max = defaultRetryMaxBackoff
+	}
+	if multiplier < 1 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(n-1))
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	half := time.Duration(delay / 2)
+	return half + rand.N(half+1)
+}
+
+// idempotent reports whether running the operation twice leaves the same data as running it once
+func (op *Operation) idempotent() bool {
+	switch op.Name {
+	case "insertOne", "insertMany", "deleteOne", "bulkWrite":
+		return false
+	case "updateOne", "updateMany", "findOneAndUpdate":
+		update, ok := op.Update.(bson.M)
+		if !ok {
+			return false
+		}
+		for name := range update {
+			if len(name) > 0 && name[0] == '$' && !idempotentOperators[name] {
+				return false
+			}
+		}
+		return true
+	case "aggregate":
+		if len(op.Pipeline) > 0 {
+			_, merge := op.Pipeline[len(op.Pipeline)-1]["$merge"]
+			return !merge
+		}
+	}
+	return true
+}
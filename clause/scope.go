@@ -0,0 +1,375 @@
+package clause
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nghialthanh/morn-go/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ErrUnscopedStage is returned when a scoped aggregate pipeline contains a stage that cannot be restricted to the scope
+var ErrUnscopedStage = errors.New("aggregation stage cannot be restricted to the scope")
+
+// ErrUnscopedModel is returned when a scoped bulk write contains a write model that cannot be restricted to the scope
+var ErrUnscopedModel = errors.New("write model cannot be restricted to the scope")
+
+// unscopedStages read data that is not made of the documents of a collection, they cannot be scoped
+var unscopedStages = map[string]bool{
+	"$changeStream":            true,
+	"$collStats":               true,
+	"$currentOp":               true,
+	"$documents":               true,
+	"$indexStats":              true,
+	"$listLocalSessions":       true,
+	"$listSampledQueries":      true,
+	"$listSearchIndexes":       true,
+	"$listSessions":            true,
+	"$planCacheStats":          true,
+	"$querySettings":           true,
+	"$shardedDataDistribution": true,
+}
+
+// searchStages must be the first stage of a pipeline, the scope is matched right after them
+var searchStages = map[string]bool{
+	"$search":       true,
+	"$searchMeta":   true,
+	"$vectorSearch": true,
+}
+
+// applyScope restricts op to the documents matching the clause scope
+func (c *Clause) applyScope(op *Operation) error {
+	switch op.Kind {
+	case KindIndex:
+		return nil
+	case KindAggregate:
+		pipeline, err := scopePipeline(c.scope, op.Pipeline)
+		if err != nil {
+			return err
+		}
+		op.Pipeline = pipeline
+		return nil
+	}
+
+	if op.Name == "bulkWrite" {
+		models, err := scopeModels(c.scope, op.Models)
+		if err != nil {
+			return err
+		}
+		op.Models = models
+		return nil
+	}
+	if op.Name == "estimatedDocumentCount" {
+		op.Name = "countDocuments"
+	}
+	if op.Filter != nil || op.Documents == nil {
+		op.Filter = scopeFilter(c.scope, op.Filter)
+	}
+	for i, doc := range op.Documents {
+		if obj, ok := doc.(bson.M); ok {
+			op.Documents[i] = scopeDocument(c.scope, obj)
+		}
+	}
+	op.Update = scopeUpdate(c.scope, op.Update)
+	return nil
+}
+
+// scopeDocument returns a copy of doc holding the fields of scope
+func scopeDocument(scope bson.M, doc bson.M) bson.M {
+	scoped := make(bson.M, len(doc)+len(scope))
+	for key, value := range doc {
+		scoped[key] = value
+	}
+	for key, value := range scope {
+		scoped[key] = value
+	}
+	return scoped
+}
+
+// scopeUpdate returns update without the operations on the fields of scope, so that it cannot move a document out of it
+func scopeUpdate(scope bson.M, update interface{}) interface{} {
+	operators, ok := update.(bson.M)
+	if !ok {
+		return update
+	}
+	scoped := make(bson.M, len(operators))
+	for name, operator := range operators {
+		fields, ok := operator.(bson.M)
+		if !ok {
+			scoped[name] = operator
+			continue
+		}
+		kept := make(bson.M, len(fields))
+		for key, value := range fields {
+			if _, ok := scope[key]; !ok {
+				kept[key] = value
+			}
+		}
+		if len(kept) > 0 {
+			scoped[name] = kept
+		}
+	}
+	if len(scoped) == 0 {
+		return update
+	}
+	return scoped
+}
+
+// scopeModels returns copies of the write models of a bulkWrite restricted to scope
+func scopeModels(scope bson.M, models []mongo.WriteModel) ([]mongo.WriteModel, error) {
+	result := make([]mongo.WriteModel, len(models))
+	for i, model := range models {
+		switch m := model.(type) {
+		case *mongo.InsertOneModel:
+			doc, err := utils.ConvToBson(m.Document)
+			if err != nil {
+				return nil, fmt.Errorf("model %d: %w", i, err)
+			}
+			scoped := *m
+			scoped.Document = scopeDocument(scope, doc)
+			result[i] = &scoped
+		case *mongo.UpdateOneModel:
+			scoped := *m
+			scoped.Filter = scopeFilter(scope, m.Filter)
+			scoped.Update = scopeUpdate(scope, m.Update)
+			result[i] = &scoped
+		case *mongo.UpdateManyModel:
+			scoped := *m
+			scoped.Filter = scopeFilter(scope, m.Filter)
+			scoped.Update = scopeUpdate(scope, m.Update)
+			result[i] = &scoped
+		case *mongo.ReplaceOneModel:
+			doc, err := utils.ConvToBson(m.Replacement)
+			if err != nil {
+				return nil, fmt.Errorf("model %d: %w", i, err)
+			}
+			scoped := *m
+			scoped.Filter = scopeFilter(scope, m.Filter)
+			scoped.Replacement = scopeDocument(scope, doc)
+			result[i] = &scoped
+		case *mongo.DeleteOneModel:
+			scoped := *m
+			scoped.Filter = scopeFilter(scope, m.Filter)
+			result[i] = &scoped
+		case *mongo.DeleteManyModel:
+			scoped := *m
+			scoped.Filter = scopeFilter(scope, m.Filter)
+			result[i] = &scoped
+		default:
+			return nil, fmt.Errorf("model %d: %T: %w", i, model, ErrUnscopedModel)
+		}
+	}
+	return result, nil
+}
+
+func scopeFilter(scope bson.M, filter interface{}) interface{} {
+	var fields map[string]interface{}
+	switch f := filter.(type) {
+	case nil:
+	case bson.M:
+		fields = f
+	case map[string]interface{}:
+		fields = f
+	default:
+		return bson.M{"$and": bson.A{scope, filter}}
+	}
+
+	result := make(bson.M, len(fields)+len(scope))
+	for key, value := range fields {
+		result[key] = value
+	}
+	for key, value := range scope {
+		if _, ok := result[key]; ok {
+			return bson.M{"$and": bson.A{scope, filter}}
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// scopePipeline returns pipeline reading only the documents matching scope, pipeline is not modified
+// A $match on scope starts the pipeline, after the $search stage when there is one, or is merged
+// into the query of a leading $geoNear. The pipelines of $lookup, $unionWith and $facet and the search
+// of $graphLookup are scoped as well, the collections they read are expected to be isolated by the same fields.
+func scopePipeline(scope bson.M, pipeline []bson.M) ([]bson.M, error) {
+	result := make([]bson.M, 0, len(pipeline)+1)
+	match := bson.M{"$match": scope}
+	rest := pipeline
+	if len(pipeline) > 0 {
+		name, _, err := stageOf(pipeline[0])
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case name == "$geoNear":
+		case searchStages[name]:
+			result = append(result, pipeline[0])
+			rest = pipeline[1:]
+			result = append(result, match)
+		default:
+			result = append(result, match)
+		}
+	} else {
+		result = append(result, match)
+	}
+
+	stages, err := scopeStages(scope, rest)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, stages...), nil
+}
+
+// scopeStages scopes the stages of pipeline reading documents other than their input
+func scopeStages(scope bson.M, pipeline []bson.M) ([]bson.M, error) {
+	result := make([]bson.M, 0, len(pipeline))
+	for _, stage := range pipeline {
+		name, value, err := stageOf(stage)
+		if err != nil {
+			return nil, err
+		}
+		if unscopedStages[name] || searchStages[name] {
+			return nil, fmt.Errorf("%s: %w", name, ErrUnscopedStage)
+		}
+
+		switch name {
+		case "$geoNear":
+			spec, err := stageDocument(name, value)
+			if err != nil {
+				return nil, err
+			}
+			spec["query"] = scopeFilter(scope, spec["query"])
+			stage = bson.M{name: spec}
+		case "$lookup":
+			spec, err := stageDocument(name, value)
+			if err != nil {
+				return nil, err
+			}
+			sub, err := subPipeline(name, spec["pipeline"])
+			if err != nil {
+				return nil, err
+			}
+			if spec["pipeline"], err = scopePipeline(scope, sub); err != nil {
+				return nil, err
+			}
+			stage = bson.M{name: spec}
+		case "$unionWith":
+			spec := bson.M{}
+			if coll, ok := value.(string); ok {
+				spec["coll"] = coll
+			} else if spec, err = stageDocument(name, value); err != nil {
+				return nil, err
+			}
+			sub, err := subPipeline(name, spec["pipeline"])
+			if err != nil {
+				return nil, err
+			}
+			if spec["pipeline"], err = scopePipeline(scope, sub); err != nil {
+				return nil, err
+			}
+			stage = bson.M{name: spec}
+		case "$graphLookup":
+			spec, err := stageDocument(name, value)
+			if err != nil {
+				return nil, err
+			}
+			spec["restrictSearchWithMatch"] = scopeFilter(scope, spec["restrictSearchWithMatch"])
+			stage = bson.M{name: spec}
+		case "$facet":
+			spec, err := stageDocument(name, value)
+			if err != nil {
+				return nil, err
+			}
+			for field, facet := range spec {
+				sub, err := subPipeline(name, facet)
+				if err != nil {
+					return nil, err
+				}
+				// the input of a facet is already scoped, only its joins are
+				if spec[field], err = scopeStages(scope, sub); err != nil {
+					return nil, err
+				}
+			}
+			stage = bson.M{name: spec}
+		}
+		result = append(result, stage)
+	}
+	return result, nil
+}
+
+// stageOf returns the name and the value of a pipeline stage
+func stageOf(stage bson.M) (string, interface{}, error) {
+	if len(stage) != 1 {
+		return "", nil, fmt.Errorf("pipeline stage %v must have exactly one field: %w", stage, ErrUnscopedStage)
+	}
+	for name, value := range stage {
+		return name, value, nil
+	}
+	return "", nil, nil
+}
+
+// stageDocument returns a copy of the specification of the stage name
+func stageDocument(name string, value interface{}) (bson.M, error) {
+	var spec bson.M
+	switch v := value.(type) {
+	case bson.M:
+		spec = make(bson.M, len(v))
+		for key, field := range v {
+			spec[key] = field
+		}
+	case map[string]interface{}:
+		spec = make(bson.M, len(v))
+		for key, field := range v {
+			spec[key] = field
+		}
+	case bson.D:
+		spec = make(bson.M, len(v))
+		for _, e := range v {
+			spec[e.Key] = e.Value
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported specification %T: %w", name, value, ErrUnscopedStage)
+	}
+	return spec, nil
+}
+
+// subPipeline returns the stages of the pipeline nested in the stage name, nil when there is none
+func subPipeline(name string, value interface{}) ([]bson.M, error) {
+	var stages []interface{}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []bson.M:
+		return v, nil
+	case bson.A:
+		stages = v
+	case []interface{}:
+		stages = v
+	case []bson.D:
+		stages = make([]interface{}, len(v))
+		for i, stage := range v {
+			stages[i] = stage
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported pipeline %T: %w", name, value, ErrUnscopedStage)
+	}
+
+	result := make([]bson.M, len(stages))
+	for i, stage := range stages {
+		switch s := stage.(type) {
+		case bson.M:
+			result[i] = s
+		case map[string]interface{}:
+			result[i] = s
+		case bson.D:
+			if len(s) != 1 {
+				return nil, fmt.Errorf("%s: pipeline stage %v must have exactly one field: %w", name, s, ErrUnscopedStage)
+			}
+			result[i] = bson.M{s[0].Key: s[0].Value}
+		default:
+			return nil, fmt.Errorf("%s: unsupported pipeline stage %T: %w", name, stage, ErrUnscopedStage)
+		}
+	}
+	return result, nil
+}
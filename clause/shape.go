@@ -0,0 +1,89 @@
+package clause
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// QueryShape renders the filter of op, or its pipeline, with every value replaced by "?"
+// Field names and operators are kept and sorted, so operations that only differ by their values share a shape.
+// It is safe to record in traces and logs, an empty string is returned when op has neither.
+// Example: {"age":{"$gt":"?"},"status":"?"}
+func (op *Operation) QueryShape() string {
+	var b strings.Builder
+	switch {
+	case op.Pipeline != nil:
+		b.WriteByte('[')
+		for n, stage := range op.Pipeline {
+			if n > 0 {
+				b.WriteByte(',')
+			}
+			writeShape(&b, stage)
+		}
+		b.WriteByte(']')
+	case op.Filter != nil:
+		writeShape(&b, op.Filter)
+	}
+	return b.String()
+}
+
+// writeShape writes the shape of a document, values that cannot be marshalled render as "?"
+func writeShape(b *strings.Builder, document interface{}) {
+	raw, ok := document.(bson.Raw)
+	if !ok {
+		data, err := bson.Marshal(document)
+		if err != nil {
+			b.WriteString(`"?"`)
+			return
+		}
+		raw = data
+	}
+	writeRawShape(b, raw)
+}
+
+func writeRawShape(b *strings.Builder, raw bson.Raw) {
+	elements, err := raw.Elements()
+	if err != nil {
+		b.WriteString(`"?"`)
+		return
+	}
+	sort.Slice(elements, func(i, j int) bool { return elements[i].Key() < elements[j].Key() })
+
+	b.WriteByte('{')
+	for n, element := range elements {
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Quote(element.Key()))
+		b.WriteByte(':')
+		writeValueShape(b, element.Value())
+	}
+	b.WriteByte('}')
+}
+
+// writeValueShape keeps embedded documents and arrays of documents, such as the operands of $and, and hides the rest
+func writeValueShape(b *strings.Builder, value bson.RawValue) {
+	switch value.Type {
+	case bson.TypeEmbeddedDocument:
+		writeRawShape(b, value.Document())
+	case bson.TypeArray:
+		values, err := value.Array().Values()
+		if err != nil || len(values) == 0 || values[0].Type != bson.TypeEmbeddedDocument {
+			b.WriteString(`"?"`)
+			return
+		}
+		b.WriteByte('[')
+		for n, item := range values {
+			if n > 0 {
+				b.WriteByte(',')
+			}
+			writeValueShape(b, item)
+		}
+		b.WriteByte(']')
+	default:
+		b.WriteString(`"?"`)
+	}
+}
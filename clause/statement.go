@@ -0,0 +1,462 @@
+package clause
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ErrDryRun is matched by the Statement returned by the terminal methods of a DryRun clause
+// The terminal methods of a DryRun clause never return a nil error, callers must test errors.Is(err, ErrDryRun)
+// before treating the error as a failure.
+var ErrDryRun = errors.New("dry run: operation not executed")
+
+// Statement is an operation rendered instead of being executed, see Clause.DryRun
+// Filter, Update, Documents and Pipeline are final: the scope of the clause is applied and the
+// CreateAtField and UpdateAtField of MornOption are set. Options merges the options of the clause.
+// Command is the equivalent database command, accepted by Database.RunCommand.
+type Statement struct {
+	Database   string
+	Collection string
+	Operation  string
+	Kind       OpKind
+	Filter     interface{}
+	Update     interface{}
+	Documents  []interface{}
+	Pipeline   []bson.M
+	Options    bson.D
+	Command    bson.D
+
+	collection *mongo.Collection
+}
+
+// Error makes the Statement the error of the terminal method that rendered it, it matches ErrDryRun
+func (s *Statement) Error() string {
+	return fmt.Sprintf("dry run: %s on %s not executed", s.Operation, s.Collection)
+}
+
+func (s *Statement) Is(target error) bool {
+	return target == ErrDryRun
+}
+
+// DryRun makes the terminal methods of the clause skip the database
+// They return the rendered *Statement as their error, which matches ErrDryRun, it is also kept by the clause
+// and returned by Statement. Before hooks run so that the Statement holds the documents they changed,
+// After hooks and decoding do not.
+// Warning:
+//   - A dry run never returns a nil error: check errors.Is(err, ErrDryRun) first, any other error is a real
+//     failure (an invalid filter, a rejected scope...) that rendered no Statement
+//   - Code written as `if err != nil { return err }` reports every dry run as a failure
+//
+// Example:
+//
+//	c := dao.Clause().DryRun()
+//	err := c.Where(bson.M{"user_id": 1}).MUpdateOne(bson.M{"email": "a@b.c"})
+//	if !errors.Is(err, clause.ErrDryRun) {
+//		return err
+//	}
+//	fmt.Println(c.Statement().Mongosh()) // db.getCollection("users").updateOne({...}, {...})
+func (c *Clause) DryRun() *Clause {
+	c.dryRun = true
+	return c
+}
+
+// Statement returns the Statement rendered by the last terminal method of a DryRun clause, nil before
+// A terminal method failing with an error other than ErrDryRun does not change it.
+func (c *Clause) Statement() *Statement {
+	return c.statement
+}
+
+// newStatement renders op once the policies of the clause changed it
+func newStatement(op *Operation) (*Statement, error) {
+	cmd, err := op.Command()
+	if err != nil {
+		return nil, err
+	}
+	opts, err := op.renderOptions()
+	if err != nil {
+		return nil, err
+	}
+	s := &Statement{
+		Operation: op.Name,
+		Kind:      op.Kind,
+		Filter:    op.Filter,
+		Update:    op.Update,
+		Documents: op.Documents,
+		Pipeline:  op.Pipeline,
+		Options:   opts,
+		Command:   cmd,
+
+		collection: op.Collection,
+	}
+	if op.Collection != nil {
+		s.Collection = op.Collection.Name()
+		s.Database = op.Collection.Database().Name()
+	}
+	return s, nil
+}
+
+// ExtJSON renders the Statement as relaxed Extended JSON
+func (s *Statement) ExtJSON() (string, error) {
+	doc := bson.D{
+		{Key: "database", Value: s.Database},
+		{Key: "collection", Value: s.Collection},
+		{Key: "operation", Value: s.Operation},
+	}
+	if s.Filter != nil {
+		doc = append(doc, bson.E{Key: "filter", Value: s.Filter})
+	}
+	if s.Update != nil {
+		doc = append(doc, bson.E{Key: "update", Value: s.Update})
+	}
+	if s.Documents != nil {
+		documents := make(bson.A, 0, len(s.Documents))
+		for _, document := range s.Documents {
+			documents = append(documents, statementDocument(document))
+		}
+		doc = append(doc, bson.E{Key: "documents", Value: documents})
+	}
+	if s.Pipeline != nil {
+		doc = append(doc, bson.E{Key: "pipeline", Value: s.Pipeline})
+	}
+	if len(s.Options) > 0 {
+		doc = append(doc, bson.E{Key: "options", Value: s.Options})
+	}
+	data, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Mongosh renders the Statement as a mongosh call, for example
+// db.getCollection("users").find({"age": {"$gt": 18}}, {}, {"limit": 10})
+func (s *Statement) Mongosh() (string, error) {
+	var args []interface{}
+	method := s.Operation
+	opts := s.Options
+	filter := s.Filter
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	switch s.Operation {
+	case "find", "findOne":
+		projection := interface{}(bson.D{})
+		if value, rest, ok := takeOption(opts, "projection"); ok {
+			projection, opts = value, rest
+		}
+		args = []interface{}{filter, projection}
+	case "countDocuments", "deleteOne", "deleteMany":
+		args = []interface{}{filter}
+	case "estimatedDocumentCount":
+	case "aggregate":
+		pipeline := s.Pipeline
+		if pipeline == nil {
+			pipeline = []bson.M{}
+		}
+		args = []interface{}{pipeline}
+	case "insertOne":
+		if len(s.Documents) != 1 {
+			return "", fmt.Errorf("insertOne: expected one document, got %d", len(s.Documents))
+		}
+		args = []interface{}{statementDocument(s.Documents[0])}
+	case "insertMany":
+		documents := make(bson.A, 0, len(s.Documents))
+		for _, document := range s.Documents {
+			documents = append(documents, statementDocument(document))
+		}
+		args = []interface{}{documents}
+	case "updateOne", "updateMany", "findOneAndUpdate":
+		args = []interface{}{filter, s.Update}
+	case "replaceOne":
+		if len(s.Documents) != 1 {
+			return "", fmt.Errorf("replaceOne: expected one replacement, got %d", len(s.Documents))
+		}
+		args = []interface{}{filter, s.Documents[0]}
+	case "createIndexes":
+		if len(s.Command) < 2 {
+			return "", errors.New("createIndexes: no index")
+		}
+		indexes, _ := s.Command[1].Value.(bson.A)
+		if len(indexes) != 1 {
+			return "", fmt.Errorf("createIndexes: expected one index, got %d", len(indexes))
+		}
+		spec, _ := indexes[0].(bson.D)
+		var keys interface{} = bson.D{}
+		indexOpts := bson.D{}
+		for _, e := range spec {
+			if e.Key == "key" {
+				keys = e.Value
+				continue
+			}
+			indexOpts = append(indexOpts, e)
+		}
+		method, args, opts = "createIndex", []interface{}{keys}, indexOpts
+	case "dropIndexes":
+		if len(s.Documents) != 1 {
+			return "", fmt.Errorf("dropIndexes: expected one index name, got %d", len(s.Documents))
+		}
+		method, args = "dropIndex", []interface{}{s.Documents[0]}
+	case "listIndexes":
+		method = "getIndexes"
+	default:
+		return "", fmt.Errorf("no mongosh method for operation %q", s.Operation)
+	}
+	if len(opts) > 0 {
+		args = append(args, opts)
+	}
+
+	rendered := make([]string, 0, len(args))
+	for _, arg := range args {
+		js, err := shellValue(arg)
+		if err != nil {
+			return "", err
+		}
+		rendered = append(rendered, js)
+	}
+	collection, _ := json.Marshal(s.Collection)
+	return fmt.Sprintf("db.getCollection(%s).%s(%s)", collection, method, strings.Join(rendered, ", ")), nil
+}
+
+// statementDocument returns document, rendered as an index specification when it is an index model
+func statementDocument(document interface{}) interface{} {
+	if model, ok := document.(mongo.IndexModel); ok {
+		if spec, err := indexSpec(model); err == nil {
+			return spec
+		}
+	}
+	return document
+}
+
+// takeOption removes key from opts
+func takeOption(opts bson.D, key string) (interface{}, bson.D, bool) {
+	for i, e := range opts {
+		if e.Key == key {
+			rest := append(bson.D{}, opts[:i]...)
+			return e.Value, append(rest, opts[i+1:]...), true
+		}
+	}
+	return nil, opts, false
+}
+
+// renderOptions returns the options set on op, named like the fields of the database commands
+func (op *Operation) renderOptions() (bson.D, error) {
+	var (
+		resolved interface{}
+		err      error
+	)
+	switch op.Name {
+	case "find":
+		resolved, err = listOptions[options.FindOptions](op.Options)
+	case "findOne":
+		resolved, err = listOptions[options.FindOneOptions](op.Options)
+	case "countDocuments":
+		resolved, err = listOptions[options.CountOptions](op.Options)
+	case "estimatedDocumentCount":
+		resolved, err = listOptions[options.EstimatedDocumentCountOptions](op.Options)
+	case "aggregate":
+		resolved, err = listOptions[options.AggregateOptions](op.Options)
+	case "insertOne":
+		resolved, err = listOptions[options.InsertOneOptions](op.Options)
+	case "insertMany":
+		resolved, err = listOptions[options.InsertManyOptions](op.Options)
+	case "updateOne":
+		resolved, err = listOptions[options.UpdateOneOptions](op.Options)
+	case "updateMany":
+		resolved, err = listOptions[options.UpdateManyOptions](op.Options)
+	case "replaceOne":
+		resolved, err = listOptions[options.ReplaceOptions](op.Options)
+	case "findOneAndUpdate":
+		resolved, err = listOptions[options.FindOneAndUpdateOptions](op.Options)
+	case "deleteOne":
+		resolved, err = listOptions[options.DeleteOneOptions](op.Options)
+	case "deleteMany":
+		resolved, err = listOptions[options.DeleteManyOptions](op.Options)
+	case "createIndexes":
+		resolved, err = listOptions[options.IndexOptions](op.Options)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	opts := bson.D{}
+	value := reflect.ValueOf(resolved).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		// Internal holds driver-only settings that are not part of the command
+		if !field.IsExported() || field.Name == "Internal" {
+			continue
+		}
+		v := value.Field(i)
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface || v.Kind() == reflect.Slice) && v.IsNil() {
+			continue
+		}
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		key := optionKey(field.Name)
+		switch option := v.Interface().(type) {
+		case time.Duration:
+			opts = append(opts, bson.E{Key: key + "MS", Value: option.Milliseconds()})
+		case options.Collation:
+			opts = append(opts, bson.E{Key: key, Value: collationDocument(&option)})
+		case options.ReturnDocument:
+			returnDocument := "before"
+			if option == options.After {
+				returnDocument = "after"
+			}
+			opts = append(opts, bson.E{Key: key, Value: returnDocument})
+		default:
+			opts = append(opts, bson.E{Key: key, Value: option})
+		}
+	}
+	return opts, nil
+}
+
+// optionKey names an options field like the database commands, for example ArrayFilters as arrayFilters
+func optionKey(name string) string {
+	switch name {
+	case "ShowRecordID":
+		return "showRecordId"
+	case "ExpireAfterSeconds":
+		return "expireAfterSeconds"
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// collationDocument renders collation with the field names of the server
+func collationDocument(collation *options.Collation) bson.D {
+	doc := bson.D{{Key: "locale", Value: collation.Locale}}
+	if collation.CaseLevel {
+		doc = append(doc, bson.E{Key: "caseLevel", Value: true})
+	}
+	if collation.CaseFirst != "" {
+		doc = append(doc, bson.E{Key: "caseFirst", Value: collation.CaseFirst})
+	}
+	if collation.Strength != 0 {
+		doc = append(doc, bson.E{Key: "strength", Value: collation.Strength})
+	}
+	if collation.NumericOrdering {
+		doc = append(doc, bson.E{Key: "numericOrdering", Value: true})
+	}
+	if collation.Alternate != "" {
+		doc = append(doc, bson.E{Key: "alternate", Value: collation.Alternate})
+	}
+	if collation.MaxVariable != "" {
+		doc = append(doc, bson.E{Key: "maxVariable", Value: collation.MaxVariable})
+	}
+	if collation.Normalization {
+		doc = append(doc, bson.E{Key: "normalization", Value: true})
+	}
+	if collation.Backwards {
+		doc = append(doc, bson.E{Key: "backwards", Value: true})
+	}
+	return doc
+}
+
+// shellValue renders value as a mongosh expression, using the shell helpers for the BSON types JSON lacks
+func shellValue(value interface{}) (string, error) {
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: value}})
+	if err != nil {
+		return "", err
+	}
+	raw, err := bson.Raw(data).LookupErr("v")
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := writeShellValue(&b, raw); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func writeShellValue(b *strings.Builder, value bson.RawValue) error {
+	switch value.Type {
+	case bson.TypeEmbeddedDocument:
+		elements, err := value.Document().Elements()
+		if err != nil {
+			return err
+		}
+		b.WriteString("{")
+		for i, element := range elements {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			key, _ := json.Marshal(element.Key())
+			b.Write(key)
+			b.WriteString(": ")
+			if err := writeShellValue(b, element.Value()); err != nil {
+				return err
+			}
+		}
+		b.WriteString("}")
+	case bson.TypeArray:
+		values, err := value.Array().Values()
+		if err != nil {
+			return err
+		}
+		b.WriteString("[")
+		for i, item := range values {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if err := writeShellValue(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteString("]")
+	case bson.TypeString:
+		s, _ := json.Marshal(value.StringValue())
+		b.Write(s)
+	case bson.TypeInt32:
+		b.WriteString(strconv.FormatInt(int64(value.Int32()), 10))
+	case bson.TypeInt64:
+		fmt.Fprintf(b, "NumberLong(%q)", strconv.FormatInt(value.Int64(), 10))
+	case bson.TypeDouble:
+		b.WriteString(strconv.FormatFloat(value.Double(), 'g', -1, 64))
+	case bson.TypeBoolean:
+		b.WriteString(strconv.FormatBool(value.Boolean()))
+	case bson.TypeNull, bson.TypeUndefined:
+		b.WriteString("null")
+	case bson.TypeObjectID:
+		fmt.Fprintf(b, "ObjectId(%q)", value.ObjectID().Hex())
+	case bson.TypeDateTime:
+		fmt.Fprintf(b, "ISODate(%q)", value.Time().UTC().Format("2006-01-02T15:04:05.000Z"))
+	case bson.TypeDecimal128:
+		fmt.Fprintf(b, "NumberDecimal(%q)", value.Decimal128().String())
+	case bson.TypeRegex:
+		pattern, flags := value.Regex()
+		s, _ := json.Marshal(pattern)
+		fmt.Fprintf(b, "RegExp(%s, %q)", s, flags)
+	case bson.TypeTimestamp:
+		t, i := value.Timestamp()
+		fmt.Fprintf(b, "Timestamp({t: %d, i: %d})", t, i)
+	case bson.TypeBinary:
+		subtype, data := value.Binary()
+		fmt.Fprintf(b, "BinData(%d, %q)", subtype, base64.StdEncoding.EncodeToString(data))
+	case bson.TypeMinKey:
+		b.WriteString("MinKey()")
+	case bson.TypeMaxKey:
+		b.WriteString("MaxKey()")
+	default:
+		// JavaScript code, symbols and DBPointers, deprecated types without shell helpers
+		b.WriteString(value.String())
+	}
+	return nil
+}
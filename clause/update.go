@@ -1,6 +1,7 @@
 package clause
 
 import (
+	"context"
 	"errors"
 	"strconv"
 
@@ -22,6 +23,11 @@ func (c *Clause) MUpdateOne(updater interface{}) error {
 		opts = c.opts.ToUpdateOne()
 	}
 
+	ctx := c.hookContext()
+	if err := callHook(updater, func(h BeforeUpdater) error { return h.BeforeUpdate(ctx) }); err != nil {
+		return err
+	}
+
 	updateField := ""
 	if c.option.UpdateAtField != "" {
 		updateField = c.option.UpdateAtField
@@ -36,17 +42,31 @@ func (c *Clause) MUpdateOne(updater interface{}) error {
 		"$set": updaterObj,
 	}
 
-	res, err := c.collection.UpdateOne(c.ctx, c.condition, updaterObj, opts)
+	op := &Operation{Kind: KindWrite, Name: "updateOne", Filter: c.condition, Update: updaterObj, Options: opts}
+	err = c.run(op, func(ctx context.Context, op *Operation) error {
+		var (
+			res *mongo.UpdateResult
+			err error
+		)
+		if op.capture {
+			res, err = op.captureUpdate(ctx)
+		} else {
+			res, err = op.Collection.UpdateOne(ctx, op.Filter, op.Update, optionsAs(op, opts))
+		}
+		if err != nil {
+			return err
+		}
 
+		if res.ModifiedCount == 0 {
+			c.logger.Warn("No document updated")
+		}
+		op.Result = res
+		return nil
+	}, nil)
 	if err != nil {
 		return err
 	}
-
-	if res.ModifiedCount == 0 {
-		c.logger.Warn("No document updated")
-	}
-
-	return nil
+	return callHook(updater, func(h AfterUpdater) error { return h.AfterUpdate(ctx) })
 }
 
 // UpdateMany updates multiple documents in the collection
@@ -56,11 +76,21 @@ func (c *Clause) MUpdateOne(updater interface{}) error {
 // - Passing a struct may reduce performance due to the use of the reflect library.
 // - If pass struct please check type of field and omitempty tag
 func (c *Clause) MUpdateMany(updater interface{}) error {
+	_, err := c.UpdateMany(updater)
+	return err
+}
+
+func (c *Clause) UpdateMany(updater interface{}) (*mongo.UpdateResult, error) {
 	var opts *options.UpdateManyOptionsBuilder = options.UpdateMany()
 	if c.opts != nil {
 		opts = c.opts.ToUpdateMany()
 	}
 
+	ctx := c.hookContext()
+	if err := callHook(updater, func(h BeforeUpdater) error { return h.BeforeUpdate(ctx) }); err != nil {
+		return nil, err
+	}
+
 	updateField := ""
 	if c.option.UpdateAtField != "" {
 		updateField = c.option.UpdateAtField
@@ -68,29 +98,55 @@ func (c *Clause) MUpdateMany(updater interface{}) error {
 
 	updaterObj, err := c.convTypeInput(updater, updateField)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	updaterObj = bson.M{
 		"$set": updaterObj,
 	}
 
-	res, err := c.collection.UpdateMany(c.ctx, c.condition, updaterObj, opts)
+	op := &Operation{Kind: KindWrite, Name: "updateMany", Filter: c.condition, Update: updaterObj, Options: opts}
+	err = c.run(op, func(ctx context.Context, op *Operation) error {
+		res, err := op.Collection.UpdateMany(ctx, op.Filter, op.Update, optionsAs(op, opts))
+		if err != nil {
+			return err
+		}
+
+		if res.ModifiedCount == 0 {
+			c.logger.Warn("No document updated")
+		}
+		op.Result = res
+		return nil
+	}, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if res.ModifiedCount == 0 {
-		c.logger.Warn("No document updated")
+	res, ok := resultAs[*mongo.UpdateResult](op)
+	if !ok {
+		return nil, ErrNoResult
+	}
+	if err := callHook(updater, func(h AfterUpdater) error { return h.AfterUpdate(ctx) }); err != nil {
+		return res, err
 	}
 
-	return nil
+	return res, nil
 }
 
-func (c *Clause) UpdateMany(updater interface{}) (*mongo.UpdateResult, error) {
-	var opts *options.UpdateManyOptionsBuilder = options.UpdateMany()
+// MReplaceOne replaces a single document in the collection
+// With replacement is a map[string]interface{} or bson.M or struct of collection, it must not contain update operators
+// Filter is a map[string]interface{} or bson.M take from Where method
+// The BeforeUpdate and AfterUpdate hooks of the replacement run around it
+// Warning:
+// - The replacement is stored as is, the CreateAtField of the replaced document is lost unless the replacement sets it
+func (c *Clause) MReplaceOne(replacement interface{}) (*mongo.UpdateResult, error) {
+	var opts *options.ReplaceOptionsBuilder = options.Replace()
 	if c.opts != nil {
-		opts = c.opts.ToUpdateMany()
+		opts = c.opts.ToReplaceOne()
+	}
+
+	ctx := c.hookContext()
+	if err := callHook(replacement, func(h BeforeUpdater) error { return h.BeforeUpdate(ctx) }); err != nil {
+		return nil, err
 	}
 
 	updateField := ""
@@ -98,22 +154,41 @@ func (c *Clause) UpdateMany(updater interface{}) (*mongo.UpdateResult, error) {
 		updateField = c.option.UpdateAtField
 	}
 
-	updaterObj, err := c.convTypeInput(updater, updateField)
+	obj, err := c.convTypeInput(replacement, updateField)
 	if err != nil {
 		return nil, err
 	}
 
-	updaterObj = bson.M{
-		"$set": updaterObj,
-	}
+	op := &Operation{Kind: KindWrite, Name: "replaceOne", Filter: c.condition, Documents: []interface{}{obj}, Options: opts}
+	err = c.run(op, func(ctx context.Context, op *Operation) error {
+		var (
+			res *mongo.UpdateResult
+			err error
+		)
+		if op.capture {
+			res, err = op.captureUpdate(ctx)
+		} else {
+			res, err = op.Collection.ReplaceOne(ctx, op.Filter, op.Documents[0], optionsAs(op, opts))
+		}
+		if err != nil {
+			return err
+		}
 
-	res, err := c.collection.UpdateMany(c.ctx, c.condition, updaterObj, opts)
+		if res.MatchedCount == 0 && res.UpsertedCount == 0 {
+			c.logger.Warn("No document replaced")
+		}
+		op.Result = res
+		return nil
+	}, nil)
 	if err != nil {
 		return nil, err
 	}
-
-	if res.ModifiedCount == 0 {
-		c.logger.Warn("No document updated")
+	res, ok := resultAs[*mongo.UpdateResult](op)
+	if !ok {
+		return nil, ErrNoResult
+	}
+	if err := callHook(replacement, func(h AfterUpdater) error { return h.AfterUpdate(ctx) }); err != nil {
+		return res, err
 	}
 
 	return res, nil
@@ -140,24 +215,20 @@ func (c *Clause) MIncreaseValue(entity interface{}, field string, upsert bool) e
 	}
 
 	opts = opts.SetUpsert(upsert)
-	res := c.collection.FindOneAndUpdate(c.ctx, c.condition, bson.M{
+	updaterObj := bson.M{
 		"$inc": bson.M{key: valInt},
-	}, opts)
-	if res == nil || res.Err() != nil {
-		if res.Err() == mongo.ErrNoDocuments {
-			return errors.New("no document found")
-		}
-		return res.Err()
 	}
 
-	if entity != nil {
-		err = c.convResultToObj(entity, res)
-		if err != nil {
-			return err
+	op := &Operation{Kind: KindWrite, Name: "findOneAndUpdate", Filter: c.condition, Update: updaterObj, Options: opts}
+	return c.run(op, c.findOneAndUpdate(opts), func(ctx context.Context, op *Operation) error {
+		if entity == nil {
+			return nil
 		}
-	}
-
-	return nil
+		if _, ok := resultAs[*mongo.SingleResult](op); !ok {
+			return ErrNoResult
+		}
+		return c.decodeInto(ctx, op, entity)
+	})
 }
 
 // FindOneAndUpdate finds a single document and updates it
@@ -165,39 +236,18 @@ func (c *Clause) MIncreaseValue(entity interface{}, field string, upsert bool) e
 // Filter is a map[string]interface{} or bson.M take from Where method
 // Record after update will be returned in entity field
 func (c *Clause) MFindOneAndUpdate(updater interface{}, entity interface{}) error {
-
-	var opts *options.FindOneAndUpdateOptionsBuilder = options.FindOneAndUpdate()
-	if c.opts != nil {
-		opts = c.opts.ToFindOneAndUpdate()
-	}
-
-	updateField := ""
-	if c.option.UpdateAtField != "" {
-		updateField = c.option.UpdateAtField
-	}
-
-	updaterObj, err := c.convTypeInput(updater, updateField)
+	res, err := c.FindOneAndUpdate(updater)
 	if err != nil {
 		return err
 	}
 
-	updaterObj = bson.M{
-		"$set": updaterObj,
-	}
-
-	res := c.collection.FindOneAndUpdate(c.ctx, c.condition, updaterObj, opts)
-	if res == nil || res.Err() != nil {
-		if res.Err() == mongo.ErrNoDocuments {
-			return errors.New("no document found")
-		}
-		return res.Err()
-	}
-
 	if entity != nil {
-		err = c.convResultToObj(entity, res)
+		err = c.convResultToObj(c.ctx, entity, res)
 		if err != nil {
 			return err
 		}
+		ctx := c.hookContext()
+		return callHook(entity, func(h AfterFinder) error { return h.AfterFind(ctx) })
 	}
 
 	return nil
@@ -210,6 +260,11 @@ func (c *Clause) FindOneAndUpdate(updater interface{}) (*mongo.SingleResult, err
 		opts = c.opts.ToFindOneAndUpdate()
 	}
 
+	ctx := c.hookContext()
+	if err := callHook(updater, func(h BeforeUpdater) error { return h.BeforeUpdate(ctx) }); err != nil {
+		return nil, err
+	}
+
 	updateField := ""
 	if c.option.UpdateAtField != "" {
 		updateField = c.option.UpdateAtField
@@ -224,13 +279,37 @@ func (c *Clause) FindOneAndUpdate(updater interface{}) (*mongo.SingleResult, err
 		"$set": updaterObj,
 	}
 
-	res := c.collection.FindOneAndUpdate(c.ctx, c.condition, updaterObj, opts)
-	if res == nil || res.Err() != nil {
-		if res.Err() == mongo.ErrNoDocuments {
-			return nil, errors.New("no document found")
-		}
-		return nil, res.Err()
+	op := &Operation{Kind: KindWrite, Name: "findOneAndUpdate", Filter: c.condition, Update: updaterObj, Options: opts}
+	err = c.run(op, c.findOneAndUpdate(opts), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, ok := resultAs[*mongo.SingleResult](op)
+	if !ok {
+		return nil, ErrNoResult
+	}
+	if err := callHook(updater, func(h AfterUpdater) error { return h.AfterUpdate(ctx) }); err != nil {
+		return res, err
 	}
 
 	return res, nil
 }
+
+func (c *Clause) findOneAndUpdate(opts *options.FindOneAndUpdateOptionsBuilder) Invoker {
+	return func(ctx context.Context, op *Operation) error {
+		res := op.Collection.FindOneAndUpdate(ctx, op.Filter, op.Update, optionsAs(op, opts))
+		if err := res.Err(); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return errors.New("no document found")
+			}
+			return err
+		}
+		if op.capture {
+			if _, err := op.keepBefore(res); err != nil {
+				return err
+			}
+		}
+		op.Result = res
+		return nil
+	}
+}
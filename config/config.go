@@ -0,0 +1,695 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/logger"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds everything needed to build an Instance
+// Keys are the same in YAML and JSON files. The environment variable of a key is the prefix
+// followed by the upper-cased key path joined with "_", for example tls.ca_file -> MORN_TLS_CA_FILE.
+// Secret keys (uri, password) can also be read from a file with the matching *_file key.
+type Config struct {
+	URI      string `json:"uri" yaml:"uri"`
+	URIFile  string `json:"uri_file" yaml:"uri_file"`
+	Database string `json:"database" yaml:"database"`
+	AppName  string `json:"app_name" yaml:"app_name"`
+
+	Username     string `json:"username" yaml:"username"`
+	Password     string `json:"password" yaml:"password"`
+	PasswordFile string `json:"password_file" yaml:"password_file"`
+	AuthSource   string `json:"auth_source" yaml:"auth_source"`
+
+	MinPoolSize     uint64   `json:"min_pool_size" yaml:"min_pool_size"`
+	MaxPoolSize     uint64   `json:"max_pool_size" yaml:"max_pool_size"`
+	MaxConnIdleTime Duration `json:"max_conn_idle_time" yaml:"max_conn_idle_time"`
+
+	ConnectTimeout         Duration `json:"connect_timeout" yaml:"connect_timeout"`
+	ServerSelectionTimeout Duration `json:"server_selection_timeout" yaml:"server_selection_timeout"`
+	PingTimeout            Duration `json:"ping_timeout" yaml:"ping_timeout"`
+	Timeout                Duration `json:"timeout" yaml:"timeout"`
+
+	OperationTimeout OperationTimeoutConfig `json:"operation_timeout" yaml:"operation_timeout"`
+	Retry            RetryConfig            `json:"retry" yaml:"retry"`
+
+	Breaker           BreakerConfig `json:"breaker" yaml:"breaker"`
+	MaxConcurrent     int           `json:"max_concurrent" yaml:"max_concurrent"`
+	MaxConcurrentWait Duration      `json:"max_concurrent_wait" yaml:"max_concurrent_wait"`
+
+	SlowQuery SlowQueryConfig `json:"slow_query" yaml:"slow_query"`
+	Audit     AuditConfig     `json:"audit" yaml:"audit"`
+
+	TLS TLSConfig `json:"tls" yaml:"tls"`
+
+	ReadPreference string             `json:"read_preference" yaml:"read_preference"`
+	ReadConcern    string             `json:"read_concern" yaml:"read_concern"`
+	WriteConcern   WriteConcernConfig `json:"write_concern" yaml:"write_concern"`
+
+	Generator GeneratorConfig `json:"generator" yaml:"generator"`
+	Fields    FieldsConfig    `json:"fields" yaml:"fields"`
+
+	LogLevel string `json:"log_level" yaml:"log_level"`
+}
+
+type TLSConfig struct {
+	Enabled            bool   `json:"enabled" yaml:"enabled"`
+	CAFile             string `json:"ca_file" yaml:"ca_file"`
+	CertFile           string `json:"cert_file" yaml:"cert_file"`
+	KeyFile            string `json:"key_file" yaml:"key_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// OperationTimeoutConfig holds the default timeouts of the Dao operations per kind
+type OperationTimeoutConfig struct {
+	Read      Duration `json:"read" yaml:"read"`
+	Write     Duration `json:"write" yaml:"write"`
+	Aggregate Duration `json:"aggregate" yaml:"aggregate"`
+	Index     Duration `json:"index" yaml:"index"`
+}
+
+// RetryConfig is the retry policy of the Dao operations, disabled while max_attempts is 0
+type RetryConfig struct {
+	MaxAttempts    int      `json:"max_attempts" yaml:"max_attempts"`
+	InitialBackoff Duration `json:"initial_backoff" yaml:"initial_backoff"`
+	MaxBackoff     Duration `json:"max_backoff" yaml:"max_backoff"`
+	Multiplier     float64  `json:"multiplier" yaml:"multiplier"`
+	NonIdempotent  bool     `json:"non_idempotent" yaml:"non_idempotent"`
+}
+
+// BreakerConfig is the circuit breaker of the Daos, see option.BreakerOption
+type BreakerConfig struct {
+	Enabled          bool     `json:"enabled" yaml:"enabled"`
+	FailureRate      float64  `json:"failure_rate" yaml:"failure_rate"`
+	MinRequests      int      `json:"min_requests" yaml:"min_requests"`
+	Window           Duration `json:"window" yaml:"window"`
+	OpenTimeout      Duration `json:"open_timeout" yaml:"open_timeout"`
+	HalfOpenRequests int      `json:"half_open_requests" yaml:"half_open_requests"`
+}
+
+// SlowQueryConfig logs the operations slower than threshold, disabled while threshold is 0
+type SlowQueryConfig struct {
+	Threshold Duration `json:"threshold" yaml:"threshold"`
+	Explain   bool     `json:"explain" yaml:"explain"`
+}
+
+// AuditConfig enables the audit trail of the Daos, see option.AuditOption
+type AuditConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	Collection   string `json:"collection" yaml:"collection"`
+	MaxDocuments int    `json:"max_documents" yaml:"max_documents"`
+}
+
+type WriteConcernConfig struct {
+	// W is "majority", a tag set name or a number of nodes
+	W       string `json:"w" yaml:"w"`
+	Journal *bool  `json:"journal" yaml:"journal"`
+}
+
+type GeneratorConfig struct {
+	Enabled       bool  `json:"enabled" yaml:"enabled"`
+	DefaultNumber int64 `json:"default_number" yaml:"default_number"`
+}
+
+type FieldsConfig struct {
+	CreatedAt string `json:"created_at" yaml:"created_at"`
+	UpdatedAt string `json:"updated_at" yaml:"updated_at"`
+}
+
+// Duration is a time.Duration written as a string such as "5s" or "250ms"
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(value)
+	return nil
+}
+
+// Error reports the configuration key that could not be loaded or is invalid
+type Error struct {
+	Key string
+	Env string
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Env != "" {
+		return fmt.Sprintf("config: %s (%s): %v", e.Key, e.Env, e.Err)
+	}
+	return fmt.Sprintf("config: %s: %v", e.Key, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Default returns the configuration used for keys that are not set
+// The client keys are left unset, the parameters of the URI or else the defaults of the driver apply.
+func Default() *Config {
+	return &Config{
+		PingTimeout: Duration(10 * time.Second),
+		Generator:   GeneratorConfig{DefaultNumber: 100000},
+		LogLevel:    "info",
+	}
+}
+
+// Load builds a configuration from the defaults, then the file at path, then the environment variables
+// starting with envPrefix, each source overriding the previous one. An empty path or prefix skips that source.
+// The file format is chosen by its extension: .yaml, .yml or .json.
+// Secret files are read and the result is validated before it is returned.
+func Load(path string, envPrefix string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if envPrefix != "" {
+		if err := cfg.loadEnv(envPrefix); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.readSecrets(envPrefix); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(envPrefix); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: read %s: %w", path, err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+	case ".json":
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	default:
+		return fmt.Errorf("config: unsupported file format %q, use .yaml, .yml or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv walks the fields of the configuration and sets the ones with a matching environment variable
+func (c *Config) loadEnv(prefix string) error {
+	return walk(reflect.ValueOf(c).Elem(), "", func(key string, field reflect.Value) error {
+		env := envName(prefix, key)
+		raw, ok := os.LookupEnv(env)
+		if !ok {
+			return nil
+		}
+		if err := setField(field, raw); err != nil {
+			return &Error{Key: key, Env: env, Err: err}
+		}
+		return nil
+	})
+}
+
+func walk(value reflect.Value, prefix string, fn func(key string, field reflect.Value) error) error {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		field := value.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := walk(field, key, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(key, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Ptr {
+		value := reflect.New(field.Type().Elem())
+		if err := setField(value.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+	if field.Type() == reflect.TypeOf(Duration(0)) {
+		return field.Addr().Interface().(*Duration).UnmarshalText([]byte(raw))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int64:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		field.SetInt(value)
+	case reflect.Float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetFloat(value)
+	case reflect.Uint64:
+		value, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		field.SetUint(value)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func envName(prefix string, key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
+
+// readSecrets replaces the secret keys with the content of their *_file key
+func (c *Config) readSecrets(prefix string) error {
+	secrets := []struct {
+		key  string
+		file string
+		dst  *string
+	}{
+		{key: "uri", file: c.URIFile, dst: &c.URI},
+		{key: "password", file: c.PasswordFile, dst: &c.Password},
+	}
+	for _, secret := range secrets {
+		if secret.file == "" {
+			continue
+		}
+		fileKey := secret.key + "_file"
+		if *secret.dst != "" {
+			return c.keyError(prefix, fileKey, fmt.Errorf("cannot be used together with %s", secret.key))
+		}
+		data, err := os.ReadFile(secret.file)
+		if err != nil {
+			return c.keyError(prefix, fileKey, err)
+		}
+		*secret.dst = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
+func (c *Config) keyError(prefix string, key string, err error) error {
+	env := ""
+	if prefix != "" {
+		env = envName(prefix, key)
+	}
+	return &Error{Key: key, Env: env, Err: err}
+}
+
+func (c *Config) validate(prefix string) error {
+	if c.URI == "" {
+		return c.keyError(prefix, "uri", errors.New("is required"))
+	}
+	if !strings.HasPrefix(c.URI, "mongodb://") && !strings.HasPrefix(c.URI, "mongodb+srv://") {
+		return c.keyError(prefix, "uri", errors.New("must start with mongodb:// or mongodb+srv://"))
+	}
+	if c.Password != "" && c.Username == "" {
+		return c.keyError(prefix, "username", errors.New("is required when password is set"))
+	}
+	if c.MaxPoolSize > 0 && c.MinPoolSize > c.MaxPoolSize {
+		return c.keyError(prefix, "min_pool_size", fmt.Errorf("%d is greater than max_pool_size %d", c.MinPoolSize, c.MaxPoolSize))
+	}
+
+	durations := []struct {
+		key   string
+		value Duration
+	}{
+		{"max_conn_idle_time", c.MaxConnIdleTime},
+		{"connect_timeout", c.ConnectTimeout},
+		{"server_selection_timeout", c.ServerSelectionTimeout},
+		{"ping_timeout", c.PingTimeout},
+		{"timeout", c.Timeout},
+		{"operation_timeout.read", c.OperationTimeout.Read},
+		{"operation_timeout.write", c.OperationTimeout.Write},
+		{"operation_timeout.aggregate", c.OperationTimeout.Aggregate},
+		{"operation_timeout.index", c.OperationTimeout.Index},
+		{"retry.initial_backoff", c.Retry.InitialBackoff},
+		{"retry.max_backoff", c.Retry.MaxBackoff},
+		{"breaker.window", c.Breaker.Window},
+		{"breaker.open_timeout", c.Breaker.OpenTimeout},
+		{"max_concurrent_wait", c.MaxConcurrentWait},
+		{"slow_query.threshold", c.SlowQuery.Threshold},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			return c.keyError(prefix, d.key, fmt.Errorf("must not be negative, got %s", time.Duration(d.value)))
+		}
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return c.keyError(prefix, "tls.key_file", errors.New("tls.cert_file and tls.key_file must be set together"))
+	}
+	for key, path := range map[string]string{"tls.ca_file": c.TLS.CAFile, "tls.cert_file": c.TLS.CertFile, "tls.key_file": c.TLS.KeyFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return c.keyError(prefix, key, err)
+		}
+	}
+
+	if _, err := c.readPref(); err != nil {
+		return c.keyError(prefix, "read_preference", err)
+	}
+	if _, err := c.readConcern(); err != nil {
+		return c.keyError(prefix, "read_concern", err)
+	}
+	if _, err := c.writeConcern(); err != nil {
+		return c.keyError(prefix, "write_concern.w", err)
+	}
+	if c.Retry.MaxAttempts < 0 {
+		return c.keyError(prefix, "retry.max_attempts", fmt.Errorf("must not be negative, got %d", c.Retry.MaxAttempts))
+	}
+	if c.Retry.Multiplier != 0 && c.Retry.Multiplier < 1 {
+		return c.keyError(prefix, "retry.multiplier", fmt.Errorf("must be at least 1, got %g", c.Retry.Multiplier))
+	}
+	if c.Breaker.FailureRate < 0 || c.Breaker.FailureRate > 1 {
+		return c.keyError(prefix, "breaker.failure_rate", fmt.Errorf("must be between 0 and 1, got %g", c.Breaker.FailureRate))
+	}
+	if c.Breaker.MinRequests < 0 || c.Breaker.HalfOpenRequests < 0 {
+		return c.keyError(prefix, "breaker.min_requests", errors.New("breaker request counts must not be negative"))
+	}
+	if c.MaxConcurrent < 0 {
+		return c.keyError(prefix, "max_concurrent", fmt.Errorf("must not be negative, got %d", c.MaxConcurrent))
+	}
+	if strings.ContainsAny(c.Audit.Collection, "$\x00") {
+		return c.keyError(prefix, "audit.collection", fmt.Errorf("invalid collection name %q", c.Audit.Collection))
+	}
+	if c.Audit.MaxDocuments < 0 {
+		return c.keyError(prefix, "audit.max_documents", fmt.Errorf("must not be negative, got %d", c.Audit.MaxDocuments))
+	}
+	if c.Generator.DefaultNumber < 0 {
+		return c.keyError(prefix, "generator.default_number", fmt.Errorf("must not be negative, got %d", c.Generator.DefaultNumber))
+	}
+	if _, err := logger.ParseLevel(c.LogLevel); err != nil {
+		return c.keyError(prefix, "log_level", fmt.Errorf("unknown level %q, use debug, info, warn or error", c.LogLevel))
+	}
+	return nil
+}
+
+func (c *Config) readPref() (*readpref.ReadPref, error) {
+	if c.ReadPreference == "" {
+		return nil, nil
+	}
+	mode, err := readpref.ModeFromString(c.ReadPreference)
+	if err != nil {
+		return nil, err
+	}
+	return readpref.New(mode)
+}
+
+func (c *Config) readConcern() (*readconcern.ReadConcern, error) {
+	switch c.ReadConcern {
+	case "":
+		return nil, nil
+	case "local":
+		return readconcern.Local(), nil
+	case "majority":
+		return readconcern.Majority(), nil
+	case "available":
+		return readconcern.Available(), nil
+	case "linearizable":
+		return readconcern.Linearizable(), nil
+	case "snapshot":
+		return readconcern.Snapshot(), nil
+	}
+	return nil, fmt.Errorf("unknown read concern %q", c.ReadConcern)
+}
+
+func (c *Config) writeConcern() (*writeconcern.WriteConcern, error) {
+	if c.WriteConcern.W == "" && c.WriteConcern.Journal == nil {
+		return nil, nil
+	}
+	wc := &writeconcern.WriteConcern{Journal: c.WriteConcern.Journal}
+	if c.WriteConcern.W != "" {
+		if nodes, err := strconv.Atoi(c.WriteConcern.W); err == nil {
+			if nodes < 0 {
+				return nil, fmt.Errorf("number of nodes must not be negative, got %d", nodes)
+			}
+			wc.W = nodes
+		} else {
+			wc.W = c.WriteConcern.W
+		}
+	}
+	return wc, nil
+}
+
+func (c *Config) tlsConfig() (*tls.Config, error) {
+	if !c.TLS.Enabled && c.TLS.CAFile == "" && c.TLS.CertFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: c.TLS.InsecureSkipVerify}
+	if c.TLS.CAFile != "" {
+		pem, err := os.ReadFile(c.TLS.CAFile)
+		if err != nil {
+			return nil, &Error{Key: "tls.ca_file", Err: err}
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, &Error{Key: "tls.ca_file", Err: errors.New("no PEM certificate found")}
+		}
+		cfg.RootCAs = pool
+	}
+	if c.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLS.CertFile, c.TLS.KeyFile)
+		if err != nil {
+			return nil, &Error{Key: "tls.cert_file", Err: err}
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// withUserinfo adds username and password to uri when it has no userinfo
+// The driver rejects a URI setting authMechanism without a username, the configured one completes it.
+func withUserinfo(uri string, username string, password string) string {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return uri
+	}
+	authority := rest
+	if end := strings.IndexAny(rest, "/?"); end >= 0 {
+		authority = rest[:end]
+	}
+	if strings.Contains(authority, "@") {
+		return uri
+	}
+	user := url.User(username)
+	if password != "" {
+		user = url.UserPassword(username, password)
+	}
+	return scheme + "://" + user.String() + "@" + rest
+}
+
+// ClientOptions returns the driver options described by the configuration, applied on top of the URI
+// Keys set in the configuration take precedence over the matching URI parameters, unset keys keep them.
+func (c *Config) ClientOptions() (*options.ClientOptions, error) {
+	uri := c.URI
+	if c.Username != "" {
+		uri = withUserinfo(uri, c.Username, c.Password)
+	}
+	opts := options.Client().ApplyURI(uri)
+	if c.AppName != "" {
+		opts.SetAppName(c.AppName)
+	}
+	if c.Username != "" {
+		// the mechanism and the fields the configuration does not set are kept from the URI
+		var credential options.Credential
+		if opts.Auth != nil {
+			credential = *opts.Auth
+		}
+		credential.Username = c.Username
+		if c.Password != "" {
+			credential.Password = c.Password
+			credential.PasswordSet = true
+		}
+		if c.AuthSource != "" {
+			credential.AuthSource = c.AuthSource
+		}
+		opts.SetAuth(credential)
+	}
+	if c.MinPoolSize > 0 {
+		opts.SetMinPoolSize(c.MinPoolSize)
+	}
+	if c.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(c.MaxPoolSize)
+	}
+	if c.MaxConnIdleTime > 0 {
+		opts.SetMaxConnIdleTime(time.Duration(c.MaxConnIdleTime))
+	}
+	if c.ConnectTimeout > 0 {
+		opts.SetConnectTimeout(time.Duration(c.ConnectTimeout))
+	}
+	if c.ServerSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(time.Duration(c.ServerSelectionTimeout))
+	}
+	if c.Timeout > 0 {
+		opts.SetTimeout(time.Duration(c.Timeout))
+	}
+
+	tlsCfg, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opts.SetTLSConfig(tlsCfg)
+	}
+
+	rp, _ := c.readPref()
+	if rp != nil {
+		opts.SetReadPreference(rp)
+	}
+	rc, _ := c.readConcern()
+	if rc != nil {
+		opts.SetReadConcern(rc)
+	}
+	wc, _ := c.writeConcern()
+	if wc != nil {
+		opts.SetWriteConcern(wc)
+	}
+	return opts, nil
+}
+
+// MornOption returns the default Dao options described by the configuration
+func (c *Config) MornOption() option.MornOption {
+	var retry *option.RetryPolicy
+	if c.Retry.MaxAttempts > 0 {
+		retry = &option.RetryPolicy{
+			MaxAttempts:        c.Retry.MaxAttempts,
+			InitialBackoff:     time.Duration(c.Retry.InitialBackoff),
+			MaxBackoff:         time.Duration(c.Retry.MaxBackoff),
+			Multiplier:         c.Retry.Multiplier,
+			RetryNonIdempotent: c.Retry.NonIdempotent,
+		}
+	}
+	var breaker *option.BreakerOption
+	if c.Breaker.Enabled {
+		breaker = &option.BreakerOption{
+			FailureRate:      c.Breaker.FailureRate,
+			MinRequests:      c.Breaker.MinRequests,
+			Window:           time.Duration(c.Breaker.Window),
+			OpenTimeout:      time.Duration(c.Breaker.OpenTimeout),
+			HalfOpenRequests: c.Breaker.HalfOpenRequests,
+		}
+	}
+	var audit *option.AuditOption
+	if c.Audit.Enabled {
+		audit = &option.AuditOption{Collection: c.Audit.Collection, MaxDocuments: c.Audit.MaxDocuments}
+	}
+	return option.MornOption{
+		IsGenID:       c.Generator.Enabled,
+		DefaultNumber: c.Generator.DefaultNumber,
+		Logger:        c.Logger(),
+		CreateAtField: c.Fields.CreatedAt,
+		UpdateAtField: c.Fields.UpdatedAt,
+
+		ReadTimeout:      time.Duration(c.OperationTimeout.Read),
+		WriteTimeout:     time.Duration(c.OperationTimeout.Write),
+		AggregateTimeout: time.Duration(c.OperationTimeout.Aggregate),
+		IndexTimeout:     time.Duration(c.OperationTimeout.Index),
+
+		Retry:             retry,
+		Breaker:           breaker,
+		MaxConcurrent:     c.MaxConcurrent,
+		MaxConcurrentWait: time.Duration(c.MaxConcurrentWait),
+
+		SlowQueryThreshold: time.Duration(c.SlowQuery.Threshold),
+		ExplainSlowQueries: c.SlowQuery.Explain,
+		Audit:              audit,
+	}
+}
+
+// Logger returns a logger filtering records below log_level
+func (c *Config) Logger() logger.ILogger {
+	level, err := logger.ParseLevel(c.LogLevel)
+	if err != nil {
+		return logger.NewFmtLogger()
+	}
+	return logger.NewLevelLogger(level)
+}
+
+// Options returns the options to pass to morn.New
+// Example:
+//
+//	cfg, err := config.Load("morn.yaml", "MORN")
+//	if err != nil {
+//		return err
+//	}
+//	opts, err := cfg.Options()
+//	if err != nil {
+//		return err
+//	}
+//	ins, err := morn.New(ctx, opts...)
+func (c *Config) Options() ([]morn.Option, error) {
+	clientOpts, err := c.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+	mornOption := c.MornOption()
+	opts := []morn.Option{
+		morn.WithClientOptions(clientOpts),
+		morn.WithLogger(mornOption.Logger),
+		morn.WithMornOption(mornOption),
+		morn.WithPingTimeout(time.Duration(c.PingTimeout)),
+	}
+	if c.Database != "" {
+		opts = append(opts, morn.WithDatabase(c.Database))
+	}
+	return opts, nil
+}
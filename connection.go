@@ -0,0 +1,214 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+// DefaultConnection is the name of the connection the Instance was built with
+const DefaultConnection = "default"
+
+var ErrUnknownConnection = errors.New("morn: unknown connection")
+
+// connection is a named deployment the Daos of an Instance can be bound to
+type connection struct {
+	db        *mongo.Database
+	fallbacks []string
+	// owned clients are connected by the Instance and disconnected with it
+	owned bool
+}
+
+// AddConnection registers db under name so Daos can read or write through it, see MornOption.ReadConnection
+// Connections must be added before the Daos using them are created.
+// fallbacks are the names of the connections tried in order when the deployment of name is unreachable,
+// they are only used by reads and aggregates. The client of db stays managed by the caller.
+// Example:
+//
+//	ins.AddConnection("analytics", analyticsClient.Database("reports"), morn.DefaultConnection)
+func (i *Instance) AddConnection(name string, db *mongo.Database, fallbacks ...string) error {
+	if db == nil {
+		return fmt.Errorf("morn: AddConnection %q: database is nil", name)
+	}
+	return i.addConnection(name, &connection{db: db, fallbacks: fallbacks})
+}
+
+func (i *Instance) addConnection(name string, conn *connection) error {
+	if name == "" || name == DefaultConnection {
+		return fmt.Errorf("morn: connection name %q is reserved", name)
+	}
+	i.connMu.Lock()
+	defer i.connMu.Unlock()
+	if i.connections == nil {
+		i.connections = make(map[string]*connection)
+	}
+	if _, ok := i.connections[name]; ok {
+		return fmt.Errorf("morn: connection %q is already registered", name)
+	}
+	i.connections[name] = conn
+	return nil
+}
+
+// Connection returns the database registered under name, DefaultConnection or an empty name return GetDB
+func (i *Instance) Connection(name string) (*mongo.Database, error) {
+	conn, err := i.connection(name)
+	if err != nil {
+		return nil, err
+	}
+	return conn.db, nil
+}
+
+func (i *Instance) connection(name string) (*connection, error) {
+	if name == "" || name == DefaultConnection {
+		return &connection{db: i.db}, nil
+	}
+	i.connMu.RLock()
+	defer i.connMu.RUnlock()
+	conn, ok := i.connections[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownConnection, name)
+	}
+	return conn, nil
+}
+
+// chain returns the databases of name followed by its fallbacks, depth first and without duplicates
+func (i *Instance) chain(name string) ([]*mongo.Database, error) {
+	var dbs []*mongo.Database
+	visited := make(map[string]bool)
+	var walk func(name string) error
+	walk = func(name string) error {
+		if name == "" {
+			name = DefaultConnection
+		}
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+		conn, err := i.connection(name)
+		if err != nil {
+			return err
+		}
+		dbs = append(dbs, conn.db)
+		for _, fallback := range conn.fallbacks {
+			if err := walk(fallback); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(name); err != nil {
+		return nil, err
+	}
+	return dbs, nil
+}
+
+// disconnectConnections disconnects the clients connected by WithConnection
+func (i *Instance) disconnectConnections(ctx context.Context) error {
+	i.connMu.RLock()
+	defer i.connMu.RUnlock()
+	var errs []error
+	for name, conn := range i.connections {
+		if !conn.owned {
+			continue
+		}
+		if err := conn.db.Client().Disconnect(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("connection %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// connectionSpec is a connection New has to connect
+type connectionSpec struct {
+	name      string
+	opts      *options.ClientOptions
+	database  string
+	fallbacks []string
+}
+
+// WithConnection connects an additional deployment registered under name, see Instance.AddConnection
+// The client is pinged like the default one and disconnected with the Instance.
+func WithConnection(name string, opts *options.ClientOptions, database string, fallbacks ...string) Option {
+	return func(c *setupConfig) error {
+		if name == "" || name == DefaultConnection {
+			return fmt.Errorf("morn: WithConnection: connection name %q is reserved", name)
+		}
+		if opts == nil {
+			return fmt.Errorf("morn: WithConnection %q: client options are nil", name)
+		}
+		if database == "" {
+			return fmt.Errorf("morn: WithConnection %q: database name is empty", name)
+		}
+		c.connections = append(c.connections, connectionSpec{name: name, opts: opts, database: database, fallbacks: fallbacks})
+		return nil
+	}
+}
+
+func (s connectionSpec) connect(ctx context.Context, cfg *setupConfig) (*connection, error) {
+	if err := s.opts.Validate(); err != nil {
+		return nil, fmt.Errorf("morn: New: connection %q: invalid client options: %w", s.name, err)
+	}
+	client, err := mongo.Connect(s.opts)
+	if err != nil {
+		return nil, fmt.Errorf("morn: New: connection %q: connect: %w", s.name, err)
+	}
+	if !cfg.skipPing {
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.pingTimeout)
+		err = client.Ping(pingCtx, readpref.Primary())
+		cancel()
+		if err != nil {
+			_ = client.Disconnect(context.WithoutCancel(ctx))
+			return nil, fmt.Errorf("morn: New: connection %q: ping primary within %s: %w", s.name, cfg.pingTimeout, err)
+		}
+	}
+	return &connection{db: client.Database(s.database), fallbacks: s.fallbacks, owned: true}, nil
+}
+
+// routes returns the collections of the Dao for every operation kind, following MornOption connections
+// Inside a session only the collections of the session client are kept, the driver rejects the others.
+func (d *Dao) routes(ctx context.Context) (map[clause.OpKind][]*mongo.Collection, bson.M, error) {
+	bindings := map[clause.OpKind]string{
+		clause.KindRead:      d.option.ReadConnection,
+		clause.KindWrite:     d.option.WriteConnection,
+		clause.KindAggregate: d.option.AggregateConnection,
+	}
+	if bindings[clause.KindAggregate] == "" {
+		bindings[clause.KindAggregate] = bindings[clause.KindRead]
+	}
+
+	session := mongo.SessionFromContext(ctx)
+	routes := make(map[clause.OpKind][]*mongo.Collection, len(bindings))
+	var scope bson.M
+	for kind, name := range bindings {
+		dbs, err := d.ins.chain(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s: %w", d.colName, kind, err)
+		}
+		for _, db := range dbs {
+			if session != nil && session.Client() != db.Client() {
+				continue
+			}
+			collection, collScope, err := d.resolveIn(ctx, db)
+			if err != nil {
+				return nil, nil, err
+			}
+			scope = collScope
+			routes[kind] = append(routes[kind], collection)
+		}
+		if len(routes[kind]) == 0 {
+			return nil, nil, fmt.Errorf("%s: %s connection %q does not belong to the client of the session", d.colName, kind, name)
+		}
+	}
+	return routes, scope, nil
+}
+
+// bound reports whether the Dao is bound to a connection other than the default one
+func (d *Dao) bound() bool {
+	return d.ins != nil && (d.option.ReadConnection != "" || d.option.WriteConnection != "" || d.option.AggregateConnection != "")
+}
@@ -3,6 +3,8 @@ package morn
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/nghialthanh/morn-go/clause"
 	"github.com/nghialthanh/morn-go/gen"
@@ -11,9 +13,11 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Dao struct {
+	ins        *Instance
 	collection *mongo.Collection
 	client     *mongo.Client
 	colName    string
@@ -22,36 +26,73 @@ type Dao struct {
 
 	option option.MornOption
 	genDao *Dao
+
+	breaker  *breaker
+	bulkhead *bulkhead
+
+	interceptors []clause.Interceptor
+	indexes      []Index
 }
 
+// NewDao creates the Dao of colName, the database of ins must be set
+// Instance.Register builds Daos without this constraint and lists them.
 func NewDao(colName string, template interface{}, ins *Instance, opt *option.MornOption) *Dao {
 	optionDao := ins.GetOptsField()
 	if opt != nil {
 		optionDao = *opt
 	}
+	if ins.GetDB() == nil {
+		ins.GetLogger().Errorf("Collection %s: database is not set, call SetDB before NewDao or use Instance.Register", colName)
+		return &Dao{ins: ins, colName: colName, template: template, option: optionDao, logger: ins.GetLogger()}
+	}
 	if optionDao.IsGenID {
 		ins.GetLogger().Infof("Generate ID for collection %s", colName)
 		ins.GenerateNewKey(colName)
 	}
-	return &Dao{
-		colName:    colName,
-		template:   template,
-		collection: ins.GetDB().Collection(colName),
-		option:     optionDao,
-		logger:     ins.GetLogger(),
-		genDao:     ins.GetDao(),
-		client:     ins.GetClient(),
+	return newDao(colName, template, ins, optionDao)
+}
+
+func newDao(colName string, template interface{}, ins *Instance, optionDao option.MornOption) *Dao {
+	dao := &Dao{
+		ins:      ins,
+		colName:  colName,
+		template: template,
+		option:   optionDao,
+		logger:   ins.GetLogger(),
+		genDao:   ins.GetDao(),
+		client:   ins.GetClient(),
+	}
+	db := ins.GetDB()
+	if optionDao.WriteConnection != "" {
+		writeDB, err := ins.Connection(optionDao.WriteConnection)
+		if err != nil {
+			ins.GetLogger().Errorf("Collection %s: %v", colName, err)
+		} else {
+			db = writeDB
+			dao.client = writeDB.Client()
+		}
+	}
+	dao.collection = dao.collectionIn(db)
+	if optionDao.Breaker != nil {
+		dao.breaker = newBreaker(colName, *optionDao.Breaker, ins.emitBreakerEvent)
+	}
+	if optionDao.MaxConcurrent > 0 {
+		dao.bulkhead = newBulkhead(colName, optionDao.MaxConcurrent, optionDao.MaxConcurrentWait, ins.GetLogger())
+	}
+	return dao
+}
+
+// collectionIn returns the collection of the Dao in db with the collection options of the Dao
+func (d *Dao) collectionIn(db *mongo.Database) *mongo.Collection {
+	var collOpts []options.Lister[options.CollectionOptions]
+	if opts := d.option.ToCollectionOptions(); opts != nil {
+		collOpts = append(collOpts, opts)
 	}
+	return db.Collection(d.colName, collOpts...)
 }
 
 func (d *Dao) Clause() *clause.Clause {
-	return clause.NewClause(
-		d.collection,
-		d.logger,
-		d.template,
-		d.option,
-		context.TODO(),
-	)
+	return d.newClause(context.TODO())
 }
 
 func (d *Dao) Ctx(ctx context.Context) *clause.Clause {
@@ -62,13 +103,44 @@ func (d *Dao) Ctx(ctx context.Context) *clause.Clause {
 		d.logger.Error("Collection not connected")
 		return nil
 	}
-	return clause.NewClause(
-		d.collection,
+	return d.newClause(ctx)
+}
+
+func (d *Dao) newClause(ctx context.Context) *clause.Clause {
+	if d.collection == nil {
+		return clause.NewClause(nil, d.logger, d.template, d.option, ctx).Fail(fmt.Errorf("%s: %w", d.colName, ErrNoDatabase))
+	}
+	var (
+		collection = d.collection
+		routes     map[clause.OpKind][]*mongo.Collection
+		scope      bson.M
+		err        error
+	)
+	if d.bound() {
+		routes, scope, err = d.routes(ctx)
+	} else {
+		collection, scope, err = d.resolve(ctx)
+	}
+	if err != nil {
+		return clause.NewClause(d.collection, d.logger, d.template, d.option, ctx).Fail(err)
+	}
+	c := clause.NewClause(
+		collection,
 		d.logger,
 		d.template,
 		d.option,
 		ctx,
-	)
+	).Scope(scope)
+	for kind, collections := range routes {
+		c.Route(kind, collections...)
+	}
+	if d.ins != nil && d.ins.tracker != nil {
+		c.Gate(d.ins.tracker)
+	}
+	if d.ins != nil && d.ins.currentMetrics() != nil {
+		c.OnRetry(d.ins.measureRetry).OnReject(d.ins.measureRejection)
+	}
+	return c.Gate(d.resilienceGates()...).Intercept(d.interceptorChain()...)
 }
 
 // ----------------------- Get/Set --------------------------//
@@ -101,11 +173,42 @@ func (d *Dao) GetOptionField() *option.MornOption {
 // This function will update the value of the document with the _id is the collection name
 // and then return the new value by plus 1
 func (d *Dao) GenIDForDao() (int64, error) {
+	return d.GenIDForDaoCtx(context.TODO())
+}
+
+// GenIDForDaoCtx is GenIDForDao running with ctx, its span is a child of the span of ctx
+func (d *Dao) GenIDForDaoCtx(ctx context.Context) (id int64, err error) {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
 	if d.genDao == nil {
 		return 0, errors.New("generator dao not found")
 	}
+	if d.ins != nil && d.ins.tracker != nil {
+		if err := d.ins.tracker.enter(nil); err != nil {
+			return 0, err
+		}
+		defer d.ins.tracker.leave()
+	}
 	generatorDao := *d.genDao
-	res := generatorDao.collection.FindOneAndUpdate(context.TODO(), bson.M{
+	ctx, span := d.startSpan(ctx, "genID "+d.colName, trace.SpanKindClient,
+		attrDBCollection.String(generatorDao.colName),
+		attrDBOperation.String("findOneAndUpdate"),
+		attrSequence.String(d.colName),
+	)
+	start := time.Now()
+	defer func() {
+		recordError(span, err)
+		span.End()
+		if d.ins == nil {
+			return
+		}
+		if metrics := d.ins.currentMetrics(); metrics != nil {
+			metrics.GenID(ctx, d.colName, time.Since(start), err)
+		}
+	}()
+
+	res := generatorDao.collection.FindOneAndUpdate(ctx, bson.M{
 		"_id": d.colName,
 	}, bson.M{
 		"$inc": bson.M{"value": 1},
@@ -118,7 +221,7 @@ func (d *Dao) GenIDForDao() (int64, error) {
 	}
 
 	var generator gen.Generator
-	err := res.Decode(&generator)
+	err = res.Decode(&generator)
 	if err != nil {
 		return 0, err
 	}
@@ -127,33 +230,67 @@ func (d *Dao) GenIDForDao() (int64, error) {
 }
 
 // Session is a function that starts a session and executes a function with the session context
-func (d *Dao) Session(ctx context.Context, f func(ctx context.Context) error, opt *option.SessionOption) error {
+// The session is started on the client of the write connection of the Dao
+// Callbacks queued with OnCommit or OnRollback inside f run once the transaction is settled
+// The transaction is traced by a span, the parent of the spans of the operations run by f
+func (d *Dao) Session(ctx context.Context, f func(ctx context.Context) error, opt *option.SessionOption) (err error) {
+	ctx, span := d.startSpan(ctx, "transaction "+d.colName, trace.SpanKindInternal, attrDBCollection.String(d.colName))
+	defer span.End()
+	start := time.Now()
+
 	session, err := d.client.StartSession()
 	if err != nil {
 		d.logger.Error("Failed to start session", err)
 		return err
 	}
 	defer session.EndSession(ctx)
+	// Shutdown cancels ctxTracked, f then fails and the transaction is aborted here
+	ctxTracked, release, err := d.ins.trackSession(ctx, session)
+	if err != nil {
+		return err
+	}
+	aborted := false
+	defer func() { release(aborted) }()
 
 	txnOptions := options.Transaction()
 	if opt != nil {
 		txnOptions = opt.ToTransactionOptions()
 	}
 
-	err = mongo.WithSession(ctx, session, func(ctxSession context.Context) error {
+	ctxCallbacks, callbacks := withTxnCallbacks(ctxTracked)
+	started := false
+	committed := false
+	err = mongo.WithSession(ctxCallbacks, session, func(ctxSession context.Context) error {
 		err := session.StartTransaction(txnOptions)
 		if err != nil {
 			return err
 		}
+		started = true
 
-		err = f(ctxSession)
+		err = f(clause.Transaction(ctxSession))
 		if err != nil {
-			session.AbortTransaction(ctxSession)
+			abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctxSession), abortTimeout)
+			defer cancel()
+			aborted = session.AbortTransaction(abortCtx) == nil
 			return err
 		}
 		// Commit the transaction
-		return session.CommitTransaction(ctxSession)
+		err = session.CommitTransaction(ctxSession)
+		committed = err == nil
+		return err
 	})
 
+	if started {
+		callbacks.run(ctx, committed, d.logger)
+	}
+	if committed {
+		span.SetAttributes(attrOutcome.String("committed"))
+	} else if started {
+		span.SetAttributes(attrOutcome.String("aborted"))
+	}
+	if metrics := d.ins.currentMetrics(); started && metrics != nil {
+		metrics.Transaction(ctx, d.colName, committed, time.Since(start))
+	}
+	recordError(span, err)
 	return err
 }
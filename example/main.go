@@ -1,23 +1,31 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/config"
 	"github.com/nghialthanh/morn-go/logger"
-	"github.com/nghialthanh/morn-go/option"
 )
 
 func main() {
 	fmt.Println("Hello, World!")
 
-	logger := logger.NewFmtLogger()
-	url := ""
-	ins, err := morn.SetupMongoByURI(url, &option.MornOption{
-		IsGenID:       true,   //auto generate id for entity
-		DefaultNumber: 100000, //default number for auto generate id
-		Logger:        logger, //logger for morn
-	})
+	//load config.yaml then the MORN_* environment variables, e.g. MORN_URI or MORN_URI_FILE
+	cfg, err := config.Load("config.yaml", "MORN")
+	if err != nil {
+		logger.NewFmtLogger().Error(err.Error())
+		return
+	}
+	logger := cfg.Logger()
+
+	opts, err := cfg.Options()
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	ins, err := morn.New(context.Background(), opts...)
 	if err != nil {
 		logger.Error(err.Error())
 		return
@@ -26,13 +34,9 @@ func main() {
 	defer func() {
 		if err = ins.Disconnect(); err != nil {
 			logger.Error("Failed to disconnect from MongoDB", err)
-			panic(err)
 		}
 	}()
 
-	//set database
-	ins.SetDB("Cluster0")
-
 	//create user repository
 	userRepository := NewUserRepository(ins)
 
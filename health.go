@@ -0,0 +1,365 @@
+package morn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nghialthanh/morn-go/logger"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// TopologyEvent types reported to the hooks registered with Instance.OnTopologyEvent
+const (
+	EventPrimaryElected   = "primary_elected"
+	EventPrimaryStepdown  = "primary_stepdown"
+	EventHeartbeatFailed  = "heartbeat_failed"
+	EventTopologyChanged  = "topology_changed"
+	EventConnectionPurged = "pool_cleared"
+)
+
+// TopologyEvent is a server or topology change observed by the driver monitors
+// For EventTopologyChanged, Address holds the new topology kind
+type TopologyEvent struct {
+	Type    string
+	Address string
+	Err     error
+	Time    time.Time
+}
+
+// HealthReport is the state of the deployment returned by Instance.Health
+type HealthReport struct {
+	Status            string         `json:"status"`
+	PrimaryReachable  bool           `json:"primary_reachable"`
+	PingLatency       time.Duration  `json:"ping_latency_ns"`
+	Topology          string         `json:"topology,omitempty"`
+	SetName           string         `json:"set_name,omitempty"`
+	Primary           string         `json:"primary,omitempty"`
+	Members           []MemberHealth `json:"members,omitempty"`
+	MaxReplicationLag time.Duration  `json:"max_replication_lag_ns"`
+	Pools             []PoolHealth   `json:"pools,omitempty"`
+	CheckedAt         time.Time      `json:"checked_at"`
+	Error             string         `json:"error,omitempty"`
+}
+
+type MemberHealth struct {
+	Address        string        `json:"address"`
+	State          string        `json:"state"`
+	Healthy        bool          `json:"healthy"`
+	LastWrite      time.Time     `json:"last_write,omitempty"`
+	ReplicationLag time.Duration `json:"replication_lag_ns"`
+}
+
+type PoolHealth struct {
+	Address string `json:"address"`
+	Open    int64  `json:"open"`
+	InUse   int64  `json:"in_use"`
+	Max     uint64 `json:"max,omitempty"`
+}
+
+// Ping checks that the primary is reachable
+func (i *Instance) Ping(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	return i.client.Ping(ctx, readpref.Primary())
+}
+
+// Health pings the primary and reports replica set members, replication lag and pool usage
+// Members, lag and pools come from the driver monitors installed by New.
+// For an Instance built by FromClient members are read with the hello command and pools are not reported.
+// The report is always returned, err is the ping error when the primary is not reachable.
+func (i *Instance) Health(ctx context.Context) (*HealthReport, error) {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	report := &HealthReport{CheckedAt: time.Now()}
+
+	start := time.Now()
+	err := i.Ping(ctx)
+	report.PingLatency = time.Since(start)
+	report.PrimaryReachable = err == nil
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	if i.monitor != nil {
+		i.monitor.fill(report)
+	} else if err == nil {
+		i.fillFromHello(ctx, report)
+	}
+
+	report.Status = HealthOK
+	switch {
+	case !report.PrimaryReachable:
+		report.Status = HealthDown
+	default:
+		for _, member := range report.Members {
+			if !member.Healthy {
+				report.Status = HealthDegraded
+			}
+		}
+	}
+	return report, err
+}
+
+// OnTopologyEvent registers fn to be called on primary elections, stepdowns, heartbeat failures and pool clears
+// Events are also logged through the logger of the Instance. fn must not block nor run operations on the Instance.
+// Only an Instance built by New observes the driver events.
+func (i *Instance) OnTopologyEvent(fn func(TopologyEvent)) *Instance {
+	if i.monitor != nil {
+		i.monitor.mu.Lock()
+		i.monitor.hooks = append(i.monitor.hooks, fn)
+		i.monitor.mu.Unlock()
+	}
+	return i
+}
+
+// LivenessHandler answers 200 while the Instance is usable, it never calls the database
+// so a MongoDB outage does not restart the pod
+func (i *Instance) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, map[string]string{"status": HealthOK})
+	})
+}
+
+// ReadinessHandler answers 200 with the HealthReport as JSON when the primary is reachable and 503 otherwise
+func (i *Instance) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, err := i.Health(r.Context())
+		status := http.StatusOK
+		if err != nil {
+			status = http.StatusServiceUnavailable
+		}
+		writeHealth(w, status, report)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (i *Instance) fillFromHello(ctx context.Context, report *HealthReport) {
+	var hello struct {
+		SetName string   `bson:"setName"`
+		Primary string   `bson:"primary"`
+		Me      string   `bson:"me"`
+		Hosts   []string `bson:"hosts"`
+		Msg     string   `bson:"msg"`
+	}
+	err := i.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		i.logger.Warn("Failed to run hello for health report", err)
+		return
+	}
+	report.SetName = hello.SetName
+	report.Primary = hello.Primary
+	switch {
+	case hello.SetName != "":
+		report.Topology = "ReplicaSet"
+	case hello.Msg == "isdbgrid":
+		report.Topology = "Sharded"
+	default:
+		report.Topology = "Single"
+	}
+	for _, host := range hello.Hosts {
+		state := "RSSecondary"
+		if host == hello.Primary {
+			state = "RSPrimary"
+		}
+		report.Members = append(report.Members, MemberHealth{Address: host, State: state, Healthy: true})
+	}
+}
+
+// ---------------------------------- monitor ----------------------------------//
+
+// monitor keeps the last topology seen by the driver and the pool usage per server
+type monitor struct {
+	logger logger.ILogger
+
+	mu       sync.RWMutex
+	topology event.TopologyDescription
+	pools    map[string]*PoolHealth
+	hooks    []func(TopologyEvent)
+}
+
+func newMonitor(log logger.ILogger) *monitor {
+	return &monitor{logger: log, pools: make(map[string]*PoolHealth)}
+}
+
+// install adds the monitor to opts, keeping the monitors already configured by the caller
+func (m *monitor) install(opts *options.ClientOptions) {
+	user := opts.ServerMonitor
+	chained := &event.ServerMonitor{}
+	if user != nil {
+		*chained = *user
+	}
+	chained.ServerDescriptionChanged = func(e *event.ServerDescriptionChangedEvent) {
+		m.serverChanged(e)
+		if user != nil && user.ServerDescriptionChanged != nil {
+			user.ServerDescriptionChanged(e)
+		}
+	}
+	chained.ServerHeartbeatFailed = func(e *event.ServerHeartbeatFailedEvent) {
+		m.emit(TopologyEvent{Type: EventHeartbeatFailed, Address: e.ConnectionID, Err: e.Failure})
+		if user != nil && user.ServerHeartbeatFailed != nil {
+			user.ServerHeartbeatFailed(e)
+		}
+	}
+	chained.TopologyDescriptionChanged = func(e *event.TopologyDescriptionChangedEvent) {
+		m.mu.Lock()
+		m.topology = e.NewDescription
+		m.mu.Unlock()
+		if e.PreviousDescription.Kind != e.NewDescription.Kind {
+			m.emit(TopologyEvent{Type: EventTopologyChanged, Address: e.NewDescription.Kind})
+		}
+		if user != nil && user.TopologyDescriptionChanged != nil {
+			user.TopologyDescriptionChanged(e)
+		}
+	}
+	opts.SetServerMonitor(chained)
+
+	userPool := opts.PoolMonitor
+	opts.SetPoolMonitor(&event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			m.poolEvent(e)
+			if userPool != nil && userPool.Event != nil {
+				userPool.Event(e)
+			}
+		},
+	})
+}
+
+func (m *monitor) serverChanged(e *event.ServerDescriptionChangedEvent) {
+	wasPrimary := e.PreviousDescription.Kind == "RSPrimary"
+	isPrimary := e.NewDescription.Kind == "RSPrimary"
+	switch {
+	case wasPrimary && !isPrimary:
+		m.emit(TopologyEvent{Type: EventPrimaryStepdown, Address: e.Address.String()})
+	case !wasPrimary && isPrimary:
+		m.emit(TopologyEvent{Type: EventPrimaryElected, Address: e.Address.String()})
+	}
+}
+
+func (m *monitor) poolEvent(e *event.PoolEvent) {
+	m.mu.Lock()
+	pool, ok := m.pools[e.Address]
+	if !ok {
+		pool = &PoolHealth{Address: e.Address}
+		m.pools[e.Address] = pool
+	}
+	switch e.Type {
+	case event.ConnectionPoolCreated:
+		if e.PoolOptions != nil {
+			pool.Max = e.PoolOptions.MaxPoolSize
+		}
+	case event.ConnectionCreated:
+		pool.Open++
+	case event.ConnectionClosed:
+		pool.Open--
+	case event.ConnectionCheckedOut:
+		pool.InUse++
+	case event.ConnectionCheckedIn:
+		pool.InUse--
+	case event.ConnectionPoolClosed:
+		delete(m.pools, e.Address)
+	}
+	m.mu.Unlock()
+
+	if e.Type == event.ConnectionPoolCleared {
+		m.emit(TopologyEvent{Type: EventConnectionPurged, Address: e.Address, Err: e.Error})
+	}
+}
+
+func (m *monitor) emit(e TopologyEvent) {
+	e.Time = time.Now()
+	switch e.Type {
+	case EventPrimaryElected:
+		m.logger.Infof("MongoDB primary elected: %s", e.Address)
+	case EventPrimaryStepdown:
+		m.logger.Warnf("MongoDB primary stepped down: %s", e.Address)
+	case EventHeartbeatFailed:
+		m.logger.Warnf("MongoDB heartbeat failed: %s (%v)", e.Address, e.Err)
+	case EventConnectionPurged:
+		m.logger.Warnf("MongoDB connection pool cleared: %s (%v)", e.Address, e.Err)
+	case EventTopologyChanged:
+		m.logger.Infof("MongoDB topology is now %s", e.Address)
+	default:
+		m.logger.Infof("MongoDB %s: %s", e.Type, e.Address)
+	}
+
+	m.mu.RLock()
+	hooks := m.hooks
+	m.mu.RUnlock()
+	for _, hook := range hooks {
+		hook(e)
+	}
+}
+
+// fill copies the monitored topology and pools into report
+func (m *monitor) fill(report *HealthReport) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	report.Topology = m.topology.Kind
+	report.SetName = m.topology.SetName
+
+	var primaryWrite time.Time
+	for _, server := range m.topology.Servers {
+		if server.Kind == "RSPrimary" {
+			report.Primary = server.Addr.String()
+			primaryWrite = server.LastWriteTime
+		}
+	}
+	for _, server := range m.topology.Servers {
+		member := MemberHealth{
+			Address:   server.Addr.String(),
+			State:     server.Kind,
+			Healthy:   server.Kind != "Unknown" && server.Kind != "",
+			LastWrite: server.LastWriteTime,
+		}
+		if server.Kind == "RSSecondary" && !primaryWrite.IsZero() && !server.LastWriteTime.IsZero() {
+			member.ReplicationLag = primaryWrite.Sub(server.LastWriteTime)
+			if member.ReplicationLag < 0 {
+				member.ReplicationLag = 0
+			}
+			if member.ReplicationLag > report.MaxReplicationLag {
+				report.MaxReplicationLag = member.ReplicationLag
+			}
+		}
+		report.Members = append(report.Members, member)
+	}
+
+	report.Pools = m.poolsLocked()
+}
+
+// poolStats returns the usage of the pool of every server, sorted by address
+func (m *monitor) poolStats() []PoolHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.poolsLocked()
+}
+
+// poolsLocked must be called with mu held
+func (m *monitor) poolsLocked() []PoolHealth {
+	var pools []PoolHealth
+	for _, pool := range m.pools {
+		pools = append(pools, *pool)
+	}
+	sort.Slice(pools, func(a, b int) bool { return pools[a].Address < pools[b].Address })
+	return pools
+}
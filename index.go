@@ -0,0 +1,623 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nghialthanh/morn-go/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// SyncMode is what SyncIndexes changes in the collection
+type SyncMode int
+
+const (
+	// SyncPlan only compares the declared indexes with the collection, for example to fail a CI job on drift
+	SyncPlan SyncMode = iota
+	// SyncCreate creates the missing indexes, stale and mismatched ones are reported
+	SyncCreate
+	// SyncDrop creates the missing indexes, drops the stale ones and recreates the mismatched ones
+	SyncDrop
+)
+
+func (m SyncMode) String() string {
+	switch m {
+	case SyncPlan:
+		return "plan"
+	case SyncCreate:
+		return "create"
+	case SyncDrop:
+		return "drop"
+	}
+	return "unknown"
+}
+
+// IndexPlan is the difference between the declared indexes of a Dao and those of its collection
+type IndexPlan struct {
+	Collection string
+	Mode       SyncMode
+	// Missing are the declared indexes the collection does not have, named
+	Missing []Index
+	// Stale are the names of the indexes of the collection that are not declared, _id_ excepted
+	Stale []string
+	// Mismatched are the declared indexes whose keys or options differ from the index of the collection
+	Mismatched []IndexMismatch
+}
+
+// IndexMismatch is an option of an index whose declared value differs from the one of the collection
+// Option is "name", "key", "unique", "sparse", "expireAfterSeconds" or "partialFilterExpression".
+type IndexMismatch struct {
+	Name     string
+	Option   string
+	Declared string
+	Existing string
+}
+
+// InSync reports whether the collection has exactly the declared indexes
+func (p *IndexPlan) InSync() bool {
+	return len(p.Missing) == 0 && len(p.Stale) == 0 && len(p.Mismatched) == 0
+}
+
+// String renders one change per line: + for missing, - for stale and ~ for mismatched indexes
+func (p *IndexPlan) String() string {
+	var b strings.Builder
+	for _, index := range p.Missing {
+		fmt.Fprintf(&b, "+ %s.%s %v\n", p.Collection, *index.Option.Name, index.Keys)
+	}
+	for _, name := range p.Stale {
+		fmt.Fprintf(&b, "- %s.%s\n", p.Collection, name)
+	}
+	for _, m := range p.Mismatched {
+		fmt.Fprintf(&b, "~ %s.%s %s: declared %s, existing %s\n", p.Collection, m.Name, m.Option, m.Declared, m.Existing)
+	}
+	return b.String()
+}
+
+// Index adds indexes to those declared by the morn tags of the template of the Dao, see SyncIndexes
+// It must be called while setting the Dao up, before it is shared between goroutines.
+func (d *Dao) Index(indexes ...Index) *Dao {
+	d.indexes = append(d.indexes, indexes...)
+	return d
+}
+
+// Indexes returns the indexes declared by the morn tags of the template of the Dao, then those added by Index
+// A field is indexed by a tag of the form index[:name][,option...], declarations are separated by ';'.
+// Fields declaring the same name form a compound index, in the order of the fields. Options are:
+//   - desc, text, hashed or 2dsphere, the type of the key of the field, ascending by default
+//   - unique and sparse
+//   - ttl=<duration>, for example ttl=720h
+//   - partial=<Extended JSON filter>, the last option as the filter may hold commas
+//
+// Example:
+//
+//	type User struct {
+//		UserID    int64      `bson:"user_id" morn:"index,unique"`
+//		Username  string     `bson:"username" morn:"index:username_email"`
+//		Email     string     `bson:"email" morn:"index:username_email;index:uniq_email,unique,partial={\"deleted\": false}"`
+//		ExpiresAt *time.Time `bson:"expires_at" morn:"index,ttl=0s"`
+//	}
+func (d *Dao) Indexes() ([]Index, error) {
+	indexes, err := tagIndexes(d.template)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", d.colName, err)
+	}
+	// an index added by Index replaces the index of the same name declared by the tags
+	indexes = append(indexes, d.indexes...)
+	declared := make([]Index, 0, len(indexes))
+	names := make(map[string]int)
+	for _, index := range indexes {
+		name := ""
+		if index.Option.Name != nil {
+			name = *index.Option.Name
+		} else if keys, err := indexKeys(index.Keys); err == nil {
+			name = indexName(keys)
+		}
+		if n, ok := names[name]; ok && name != "" {
+			declared[n] = index
+			continue
+		}
+		names[name] = len(declared)
+		declared = append(declared, index)
+	}
+	return declared, nil
+}
+
+// SyncIndexes compares the declared indexes of the Dao with those of its collection, see Indexes,
+// and applies the plan according to mode. Indexes are matched by name, then by keys.
+// With tenant isolation the collection of the tenant of ctx is synced. The returned plan is the one
+// computed before the changes, the error joins the changes that failed.
+// Example:
+//
+//	plan, err := userDao.SyncIndexes(ctx, morn.SyncPlan)
+//	if err == nil && !plan.InSync() {
+//		log.Fatalf("index drift:\n%s", plan)
+//	}
+func (d *Dao) SyncIndexes(ctx context.Context, mode SyncMode) (*IndexPlan, error) {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	declared, err := d.Indexes()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := d.newClause(ctx).ListIndexes()
+	if err != nil && !isNamespaceNotFound(err) {
+		return nil, fmt.Errorf("%s: list indexes: %w", d.colName, err)
+	}
+	plan, err := indexPlan(d.colName, mode, declared, existing)
+	if err != nil || mode == SyncPlan {
+		return plan, err
+	}
+
+	var errs []error
+	create := slices.Clone(plan.Missing)
+	if mode == SyncDrop {
+		drop := slices.Clone(plan.Stale)
+		for _, m := range plan.Mismatched {
+			if !slices.Contains(drop, m.Name) {
+				drop = append(drop, m.Name)
+				create = append(create, declaredIndex(declared, existing, m.Name))
+			}
+		}
+		for _, name := range drop {
+			if err := d.newClause(ctx).DropIndex(name); err != nil {
+				errs = append(errs, fmt.Errorf("%s: drop index %s: %w", d.colName, name, err))
+			}
+		}
+	}
+	for _, index := range create {
+		model, err := indexModel(index)
+		if err == nil {
+			_, err = d.newClause(ctx).CreateIndexModel(model)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: create index %s: %w", d.colName, *index.Option.Name, err))
+		}
+	}
+	return plan, errors.Join(errs...)
+}
+
+// indexPlan compares the declared indexes with the specifications returned by listIndexes
+func indexPlan(collection string, mode SyncMode, declared []Index, existing []bson.D) (*IndexPlan, error) {
+	plan := &IndexPlan{Collection: collection, Mode: mode}
+	matched := make(map[string]bool)
+	for _, index := range declared {
+		keys, err := indexKeys(index.Keys)
+		if err != nil {
+			return nil, fmt.Errorf("%s: index %v: %w", collection, index.Keys, err)
+		}
+		want, err := declaredState(index, keys)
+		if err != nil {
+			return nil, fmt.Errorf("%s: index %s: %w", collection, want.name, err)
+		}
+		index.Option.Name = &want.name
+
+		var got *indexState
+		for _, spec := range existing {
+			state := existingState(spec)
+			if state.name == want.name {
+				got = &state
+				break
+			}
+		}
+		if got == nil {
+			for _, spec := range existing {
+				state := existingState(spec)
+				if state.key == want.key && !matched[state.name] {
+					got = &state
+					break
+				}
+			}
+		}
+		if got == nil {
+			plan.Missing = append(plan.Missing, index)
+			continue
+		}
+		matched[got.name] = true
+		plan.Mismatched = append(plan.Mismatched, want.diff(*got)...)
+	}
+	for _, spec := range existing {
+		state := existingState(spec)
+		if state.name != "_id_" && !matched[state.name] {
+			plan.Stale = append(plan.Stale, state.name)
+		}
+	}
+	return plan, nil
+}
+
+// declaredIndex returns the declared index matched to the existing index name
+func declaredIndex(declared []Index, existing []bson.D, name string) Index {
+	key := ""
+	for _, spec := range existing {
+		if state := existingState(spec); state.name == name {
+			key = state.key
+		}
+	}
+	for _, index := range declared {
+		keys, _ := indexKeys(index.Keys)
+		want, _ := declaredState(index, keys)
+		if want.name == name || want.key == key {
+			index.Option.Name = &want.name
+			return index
+		}
+	}
+	return Index{}
+}
+
+// indexState holds the compared options of an index, rendered as strings
+type indexState struct {
+	name    string
+	key     string
+	unique  bool
+	sparse  bool
+	ttl     string
+	partial string
+}
+
+func (s indexState) diff(existing indexState) []IndexMismatch {
+	var diffs []IndexMismatch
+	add := func(option, declared, got string) {
+		if declared != got {
+			diffs = append(diffs, IndexMismatch{Name: existing.name, Option: option, Declared: declared, Existing: got})
+		}
+	}
+	add("name", s.name, existing.name)
+	add("key", s.key, existing.key)
+	add("unique", strconv.FormatBool(s.unique), strconv.FormatBool(existing.unique))
+	add("sparse", strconv.FormatBool(s.sparse), strconv.FormatBool(existing.sparse))
+	add("expireAfterSeconds", s.ttl, existing.ttl)
+	add("partialFilterExpression", s.partial, existing.partial)
+	return diffs
+}
+
+func declaredState(index Index, keys bson.D) (indexState, error) {
+	state := indexState{name: indexName(keys), key: keyString(keys)}
+	opts := index.Option
+	if opts.Name != nil {
+		state.name = *opts.Name
+	}
+	state.unique = opts.Unique != nil && *opts.Unique
+	state.sparse = opts.Sparse != nil && *opts.Sparse
+	if opts.ExpireAfterSeconds != nil {
+		state.ttl = strconv.Itoa(int(*opts.ExpireAfterSeconds))
+	}
+	if opts.PartialFilterExpression != nil {
+		partial, err := canonicalJSON(opts.PartialFilterExpression)
+		if err != nil {
+			return state, fmt.Errorf("partial filter: %w", err)
+		}
+		state.partial = partial
+	}
+	return state, nil
+}
+
+func existingState(spec bson.D) indexState {
+	doc := make(bson.M, len(spec))
+	for _, e := range spec {
+		doc[e.Key] = e.Value
+	}
+	state := indexState{}
+	state.name, _ = doc["name"].(string)
+	state.unique, _ = doc["unique"].(bool)
+	state.sparse, _ = doc["sparse"].(bool)
+	if ttl, ok := indexInt(doc["expireAfterSeconds"]); ok {
+		state.ttl = strconv.FormatInt(ttl, 10)
+	}
+	if partial, ok := doc["partialFilterExpression"]; ok {
+		state.partial, _ = canonicalJSON(partial)
+	}
+
+	key, _ := doc["key"].(bson.D)
+	weights, _ := doc["weights"].(bson.D)
+	keys := bson.D{}
+	for _, e := range key {
+		switch e.Key {
+		case "_fts":
+			// the fields of text indexes are listed in their weights
+			for _, w := range weights {
+				keys = append(keys, bson.E{Key: w.Key, Value: "text"})
+			}
+		case "_ftsx":
+		default:
+			keys = append(keys, e)
+		}
+	}
+	state.key = keyString(keys)
+	return state
+}
+
+// keyString renders keys as "field:value" pairs, the fields of a text index sorted as their order is not kept
+func keyString(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	var text []string
+	for _, e := range keys {
+		value := fmt.Sprint(e.Value)
+		if n, ok := indexInt(e.Value); ok {
+			value = strconv.FormatInt(n, 10)
+		}
+		if value == "text" {
+			if text == nil {
+				parts = append(parts, "")
+			}
+			text = append(text, e.Key+":text")
+			continue
+		}
+		parts = append(parts, e.Key+":"+value)
+	}
+	sort.Strings(text)
+	for i, part := range parts {
+		if part == "" {
+			parts[i] = strings.Join(text, ",")
+		}
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// indexName returns the name the server gives to an index of keys, for example "username_1_email_-1"
+func indexName(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, e := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", e.Key, e.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// indexKeys converts keys of the format of Clause.CreateIndex, the value of a key being a direction or an index type
+func indexKeys(keys []string) (bson.D, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("index without keys")
+	}
+	doc := make(bson.D, 0, len(keys))
+	for _, key := range keys {
+		field, value, err := utils.ConvKeyValue(key)
+		if err != nil {
+			return nil, err
+		}
+		if direction, err := strconv.Atoi(value); err == nil {
+			doc = append(doc, bson.E{Key: field, Value: direction})
+		} else {
+			doc = append(doc, bson.E{Key: field, Value: value})
+		}
+	}
+	return doc, nil
+}
+
+func indexModel(index Index) (mongo.IndexModel, error) {
+	keys, err := indexKeys(index.Keys)
+	if err != nil {
+		return mongo.IndexModel{}, err
+	}
+	return mongo.IndexModel{Keys: keys, Options: index.Option.ToCreateIndex()}, nil
+}
+
+// canonicalJSON renders value as relaxed Extended JSON with the fields of its documents sorted
+func canonicalJSON(value interface{}) (string, error) {
+	raw, err := bson.Marshal(bson.D{{Key: "v", Value: value}})
+	if err != nil {
+		return "", err
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return "", err
+	}
+	out, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: sortDocument(doc[0].Value)}}, false, false)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(out), `{"v":`), "}"), nil
+}
+
+func sortDocument(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		sorted := make(bson.D, 0, len(v))
+		for _, e := range v {
+			sorted = append(sorted, bson.E{Key: e.Key, Value: sortDocument(e.Value)})
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+		return sorted
+	case bson.A:
+		sorted := make(bson.A, 0, len(v))
+		for _, item := range v {
+			sorted = append(sorted, sortDocument(item))
+		}
+		return sorted
+	}
+	return value
+}
+
+func indexInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// tagIndexes returns the indexes declared by the morn tags of the fields of template, see Dao.Indexes
+func tagIndexes(template interface{}) ([]Index, error) {
+	if template == nil {
+		return nil, nil
+	}
+	var (
+		indexes []Index
+		named   = make(map[string]int)
+	)
+	var walk func(typ reflect.Type, prefix string, visiting map[reflect.Type]bool) error
+	walk = func(typ reflect.Type, prefix string, visiting map[reflect.Type]bool) error {
+		typ = modelType(typ)
+		if typ.Kind() != reflect.Struct || visiting[typ] {
+			return nil
+		}
+		visiting[typ] = true
+		defer delete(visiting, typ)
+
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, inline := bsonField(field)
+			if name == "-" {
+				continue
+			}
+			path := prefix + name
+			if inline {
+				path = strings.TrimSuffix(prefix, ".")
+			}
+			if tag, ok := field.Tag.Lookup("morn"); ok {
+				for _, declaration := range strings.Split(tag, ";") {
+					if strings.TrimSpace(declaration) == "" {
+						continue
+					}
+					index, key, err := parseIndexTag(declaration)
+					if err != nil {
+						return fmt.Errorf("field %s.%s: %w", typ.Name(), field.Name, err)
+					}
+					index.Keys = []string{path + ":" + key}
+					if index.Option.Name == nil {
+						indexes = append(indexes, index)
+						continue
+					}
+					n, ok := named[*index.Option.Name]
+					if !ok {
+						named[*index.Option.Name] = len(indexes)
+						indexes = append(indexes, index)
+						continue
+					}
+					mergeIndex(&indexes[n], index)
+				}
+			}
+			next := path + "."
+			if inline {
+				next = prefix
+			}
+			if err := walk(field.Type, next, visiting); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(reflect.TypeOf(template), "", make(map[reflect.Type]bool)); err != nil {
+		return nil, err
+	}
+	return indexes, nil
+}
+
+// bsonField returns the name of field in documents, like the driver, and whether its fields are inlined
+func bsonField(field reflect.StructField) (string, bool) {
+	name := strings.ToLower(field.Name)
+	tag, ok := field.Tag.Lookup("bson")
+	if !ok {
+		return name, false
+	}
+	if tag == "-" {
+		return "-", false
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, part := range parts[1:] {
+		if part == "inline" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+// parseIndexTag parses a declaration of a morn tag and returns the index and the key type of the field
+func parseIndexTag(declaration string) (Index, string, error) {
+	declaration = strings.TrimSpace(declaration)
+	head, rest, _ := strings.Cut(declaration, ",")
+	kind, name, _ := strings.Cut(head, ":")
+	if kind != "index" {
+		return Index{}, "", fmt.Errorf("unknown morn tag %q", declaration)
+	}
+
+	index := Index{}
+	if name != "" {
+		index.Option.Name = &name
+	}
+	key := "1"
+	for rest != "" {
+		var item string
+		if strings.HasPrefix(rest, "partial=") {
+			item, rest = rest, ""
+		} else {
+			item, rest, _ = strings.Cut(rest, ",")
+		}
+		option, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch option {
+		case "desc":
+			key = "-1"
+		case "text", "hashed", "2dsphere":
+			key = option
+		case "unique":
+			index.Option.Unique = boolPtr(true)
+		case "sparse":
+			index.Option.Sparse = boolPtr(true)
+		case "ttl":
+			ttl, err := time.ParseDuration(value)
+			if err != nil {
+				return Index{}, "", fmt.Errorf("index ttl: %w", err)
+			}
+			if ttl < 0 || ttl/time.Second > math.MaxInt32 {
+				return Index{}, "", fmt.Errorf("index ttl %s: must be between 0s and %ds", value, math.MaxInt32)
+			}
+			seconds := int32(ttl / time.Second)
+			index.Option.ExpireAfterSeconds = &seconds
+		case "partial":
+			var filter bson.D
+			if err := bson.UnmarshalExtJSON([]byte(value), false, &filter); err != nil {
+				return Index{}, "", fmt.Errorf("index partial filter: %w", err)
+			}
+			index.Option.PartialFilterExpression = filter
+		default:
+			return Index{}, "", fmt.Errorf("unknown index option %q", option)
+		}
+	}
+	return index, key, nil
+}
+
+// mergeIndex adds the key and the options of a field of the compound index into
+func mergeIndex(into *Index, field Index) {
+	into.Keys = append(into.Keys, field.Keys...)
+	opts, add := &into.Option, field.Option
+	if add.Unique != nil {
+		opts.Unique = add.Unique
+	}
+	if add.Sparse != nil {
+		opts.Sparse = add.Sparse
+	}
+	if add.ExpireAfterSeconds != nil {
+		opts.ExpireAfterSeconds = add.ExpireAfterSeconds
+	}
+	if add.PartialFilterExpression != nil {
+		opts.PartialFilterExpression = add.PartialFilterExpression
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func isNamespaceNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 26
+}
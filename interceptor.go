@@ -0,0 +1,52 @@
+package morn
+
+import "github.com/nghialthanh/morn-go/clause"
+
+// Use adds interceptors around every operation of every Dao of the Instance
+// Interceptors of the Instance wrap those of the Dao, the first one added is the outermost. They apply to
+// the clauses created after the call, see clause.Interceptor.
+// Example:
+//
+//	ins.Use(func(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+//		start := time.Now()
+//		err := next(ctx, op)
+//		log.Printf("%s.%s took %s", op.Collection.Name(), op.Name, time.Since(start))
+//		return err
+//	})
+func (i *Instance) Use(interceptors ...clause.Interceptor) *Instance {
+	i.hooksMu.Lock()
+	i.interceptors = append(i.interceptors, interceptors...)
+	i.hooksMu.Unlock()
+	return i
+}
+
+// Use adds interceptors around every operation of the Dao, inside those of the Instance
+// It must be called while setting the Dao up, before it is shared between goroutines.
+func (d *Dao) Use(interceptors ...clause.Interceptor) *Dao {
+	d.interceptors = append(d.interceptors, interceptors...)
+	return d
+}
+
+// interceptorChain returns the tracing, metrics and slow query interceptors,
+// then the interceptors of the Instance and those of the Dao, then the audit trail
+// The audit trail is the innermost so that it records the operation as changed by the other interceptors.
+func (d *Dao) interceptorChain() []clause.Interceptor {
+	var chain []clause.Interceptor
+	if d.ins != nil {
+		chain = append(chain, d.ins.traceOperation)
+		if d.ins.currentMetrics() != nil {
+			chain = append(chain, d.ins.measureOperation)
+		}
+		if d.option.SlowQueryThreshold > 0 {
+			chain = append(chain, d.slowQueryLog)
+		}
+		d.ins.hooksMu.RLock()
+		chain = append(chain, d.ins.interceptors...)
+		d.ins.hooksMu.RUnlock()
+	}
+	chain = append(chain, d.interceptors...)
+	if d.option.Audit != nil {
+		chain = append(chain, d.auditTrail)
+	}
+	return chain
+}
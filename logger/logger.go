@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"log/slog"
+	"os"
 )
 
 type ILogger interface {
@@ -16,40 +17,63 @@ type ILogger interface {
 	Debugf(format string, args ...interface{})
 }
 
-type SlogLogger struct{}
+type SlogLogger struct {
+	logger *slog.Logger
+}
 
 func NewFmtLogger() ILogger {
 	return &SlogLogger{}
 }
 
+// NewLevelLogger returns a logger writing text records to stderr from level up
+func NewLevelLogger(level slog.Level) ILogger {
+	return &SlogLogger{
+		logger: slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})),
+	}
+}
+
+// ParseLevel converts debug, info, warn or error to a slog level
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(level))
+	return l, err
+}
+
+func (l *SlogLogger) get() *slog.Logger {
+	if l.logger == nil {
+		return slog.Default()
+	}
+	return l.logger
+}
+
 func (l *SlogLogger) Info(msg string, args ...interface{}) {
-	slog.Info(msg, args...)
+	l.get().Info(msg, args...)
 }
 
 func (l *SlogLogger) Infof(format string, args ...interface{}) {
-	slog.Info(fmt.Sprintf(format, args...))
+	l.get().Info(fmt.Sprintf(format, args...))
 }
 
 func (l *SlogLogger) Error(msg string, args ...interface{}) {
-	slog.Error(msg, args...)
+	l.get().Error(msg, args...)
 }
 
 func (l *SlogLogger) Errorf(format string, args ...interface{}) {
-	slog.Error(fmt.Sprintf(format, args...))
+	l.get().Error(fmt.Sprintf(format, args...))
 }
 
 func (l *SlogLogger) Warn(msg string, args ...interface{}) {
-	slog.Warn(msg, args...)
+	l.get().Warn(msg, args...)
 }
 
 func (l *SlogLogger) Warnf(format string, args ...interface{}) {
-	slog.Warn(fmt.Sprintf(format, args...))
+	l.get().Warn(fmt.Sprintf(format, args...))
 }
 
 func (l *SlogLogger) Debug(msg string, args ...interface{}) {
-	slog.Debug(msg, args...)
+	l.get().Debug(msg, args...)
 }
 
 func (l *SlogLogger) Debugf(format string, args ...interface{}) {
-	slog.Debug(fmt.Sprintf(format, args...))
+	l.get().Debug(fmt.Sprintf(format, args...))
 }
@@ -0,0 +1,192 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/x/mongo/driver/topology"
+)
+
+// Error classes reported by ErrorClass
+const (
+	ErrorClassTimeout      = "timeout"
+	ErrorClassCanceled     = "canceled"
+	ErrorClassNetwork      = "network"
+	ErrorClassUnavailable  = "unavailable"
+	ErrorClassDuplicateKey = "duplicate_key"
+	ErrorClassNotFound     = "not_found"
+	ErrorClassCircuitOpen  = "circuit_open"
+	ErrorClassBulkheadFull = "bulkhead_full"
+	ErrorClassRejected     = "rejected"
+	ErrorClassServer       = "server"
+	ErrorClassClient       = "client"
+)
+
+// Metrics receives the measurements of an Instance, see NewOtelMetrics for an OpenTelemetry implementation
+// Methods are called on the goroutine of the operation and must not block.
+type Metrics interface {
+	// Operation is called once every clause operation finished, retries included
+	Operation(ctx context.Context, m OperationMetric)
+	// Retry is called before an operation is retried, attempt is the number of the failed attempt
+	Retry(ctx context.Context, collection string, operation string, attempt int, err error)
+	// Transaction is called once a transaction started by Dao.Session is committed or aborted
+	Transaction(ctx context.Context, collection string, committed bool, duration time.Duration)
+	// GenID is called after every allocation of GenIDForDao
+	GenID(ctx context.Context, collection string, duration time.Duration, err error)
+	// Breaker is called when the circuit breaker of a Dao changes state
+	Breaker(event BreakerEvent)
+	// ObservePools is called once when the Metrics is set, read returns the current usage of the connection pools
+	// stop is called when the Metrics is replaced or removed, read must not be called afterwards.
+	ObservePools(read func() []PoolHealth) (stop func() error, err error)
+}
+
+// OperationMetric is the measurement of one clause operation
+// Returned counts the documents decoded by the terminal method, see Operation.OnDecoded, the documents
+// of a cursor returned to the caller are not counted. Modified counts the documents inserted, updated,
+// upserted or deleted. Err is the error of the operation or, once it succeeded, of the decoding.
+type OperationMetric struct {
+	Collection string
+	Operation  string
+	Kind       clause.OpKind
+	Duration   time.Duration
+	Returned   int64
+	Modified   int64
+	Err        error
+}
+
+// SetMetrics sets the Metrics the Instance reports to, nil stops reporting
+// The pools observed by the previous Metrics are released. Clauses created before the call report to
+// the Metrics set when they run.
+func (i *Instance) SetMetrics(metrics Metrics) error {
+	var stop func() error
+	if metrics != nil {
+		var err error
+		if stop, err = metrics.ObservePools(i.poolStats); err != nil {
+			return err
+		}
+	}
+
+	i.metricsMu.Lock()
+	previous := i.stopPools
+	i.metrics, i.stopPools = metrics, stop
+	i.metricsMu.Unlock()
+	if previous != nil {
+		return previous()
+	}
+	return nil
+}
+
+// currentMetrics returns the Metrics of the Instance, nil when there is none
+func (i *Instance) currentMetrics() Metrics {
+	i.metricsMu.RLock()
+	defer i.metricsMu.RUnlock()
+	return i.metrics
+}
+
+// WithMetrics sets the Metrics the Instance reports to, see Instance.SetMetrics
+func WithMetrics(metrics Metrics) Option {
+	return func(c *setupConfig) error {
+		if metrics == nil {
+			return errors.New("morn: WithMetrics: metrics is nil")
+		}
+		c.metrics = metrics
+		return nil
+	}
+}
+
+// poolStats returns the usage of the connection pools, only monitored for the Instances built by New
+func (i *Instance) poolStats() []PoolHealth {
+	if i.monitor == nil {
+		return nil
+	}
+	return i.monitor.poolStats()
+}
+
+// measureOperation is the interceptor reporting every operation to the Metrics of the Instance
+// The metric is reported once the result is decoded, so that it counts the returned documents.
+func (i *Instance) measureOperation(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+	start := time.Now()
+	err := next(ctx, op)
+	metrics := i.currentMetrics()
+	if metrics == nil {
+		return err
+	}
+	metric := OperationMetric{
+		Collection: op.Collection.Name(),
+		Operation:  op.Name,
+		Kind:       op.Kind,
+		Duration:   time.Since(start),
+		Err:        err,
+	}
+	switch res := op.Result.(type) {
+	case *mongo.InsertOneResult:
+		metric.Modified = 1
+	case *mongo.InsertManyResult:
+		metric.Modified = int64(len(res.InsertedIDs))
+	case *mongo.UpdateResult:
+		metric.Modified = res.ModifiedCount + res.UpsertedCount
+	case *mongo.DeleteResult:
+		metric.Modified = res.DeletedCount
+	case *mongo.BulkWriteResult:
+		metric.Modified = res.InsertedCount + res.ModifiedCount + res.UpsertedCount + res.DeletedCount
+	}
+	op.OnDecoded(func(returned int64, err error) {
+		metric.Returned = returned
+		if metric.Err == nil {
+			metric.Err = err
+		}
+		metrics.Operation(ctx, metric)
+	})
+	return err
+}
+
+// measureRejection reports the operations rejected by a gate, they never reach measureOperation
+func (i *Instance) measureRejection(ctx context.Context, op *clause.Operation, err error) {
+	if metrics := i.currentMetrics(); metrics != nil {
+		metrics.Operation(ctx, OperationMetric{
+			Collection: op.Collection.Name(),
+			Operation:  op.Name,
+			Kind:       op.Kind,
+			Err:        err,
+		})
+	}
+}
+
+func (i *Instance) measureRetry(ctx context.Context, op *clause.Operation, attempt int, err error) {
+	if metrics := i.currentMetrics(); metrics != nil {
+		metrics.Retry(ctx, op.Collection.Name(), op.Name, attempt, err)
+	}
+}
+
+// ErrorClass classifies err for metrics, an empty string is returned for nil
+func ErrorClass(err error) string {
+	var serverErr mongo.ServerError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrorClassCircuitOpen
+	case errors.Is(err, ErrBulkheadFull):
+		return ErrorClassBulkheadFull
+	case errors.Is(err, ErrShuttingDown), errors.Is(err, clause.ErrReadOnly):
+		return ErrorClassRejected
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrorClassNotFound
+	case mongo.IsTimeout(err):
+		return ErrorClassTimeout
+	case mongo.IsNetworkError(err):
+		return ErrorClassNetwork
+	case errors.Is(err, mongo.ErrClientDisconnected), errors.As(err, &topology.ServerSelectionError{}):
+		return ErrorClassUnavailable
+	case mongo.IsDuplicateKeyError(err):
+		return ErrorClassDuplicateKey
+	case errors.As(err, &serverErr):
+		return ErrorClassServer
+	}
+	return ErrorClassClient
+}
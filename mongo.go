@@ -3,13 +3,17 @@ package morn
 import (
 	"context"
 	"errors"
+	"reflect"
+	"sync"
 
+	"github.com/nghialthanh/morn-go/clause"
 	"github.com/nghialthanh/morn-go/gen"
 	"github.com/nghialthanh/morn-go/logger"
 	"github.com/nghialthanh/morn-go/option"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Instance struct {
@@ -20,50 +24,72 @@ type Instance struct {
 	optField *option.MornOption
 	logger   logger.ILogger
 	genDao   *Dao
+
+	tenancy        TenantStrategy
+	tenantResolver TenantResolver
+	monitor        *monitor
+	tracker        *tracker
+
+	connMu      sync.RWMutex
+	connections map[string]*connection
+
+	hooksMu      sync.RWMutex
+	breakerHooks []func(BreakerEvent)
+	interceptors []clause.Interceptor
+
+	tracerProvider trace.TracerProvider
+	explainer      explainer
+
+	metricsMu sync.RWMutex
+	metrics   Metrics
+	stopPools func() error
+
+	registryMu sync.RWMutex
+	models     map[string]*registered
+	modelTypes map[reflect.Type]string
 }
 
+var ErrNoDatabase = errors.New("morn: database is not set")
+
 // SetupMongo with default options
 // If you want to use custom options, you can config inside url or manual setup by SetupMongoByClient method
+//
+// Deprecated: use New with WithURI and WithServerAPI, which also validates the configuration and pings the deployment.
 func SetupMongoByURI(uri string, opts *option.MornOption) (*Instance, error) {
 	if uri == "" {
 		return nil, errors.New("uri is required")
 	}
-
-	ins := Instance{
-		optField: opts,
-		logger:   opts.Logger,
-	}
-
-	if opts.Logger == nil {
-		ins.logger = logger.NewFmtLogger()
-	}
-
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-
-	client, err := mongo.Connect(options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI))
-	if err != nil {
-		ins.logger.Error("Failed to connect to MongoDB", err)
-		return nil, err
+	if opts == nil {
+		opts = &option.MornOption{}
 	}
 
-	ins.client = client
-
-	return &ins, nil
+	return New(context.Background(),
+		WithURI(uri),
+		WithServerAPI(options.ServerAPIVersion1),
+		WithMornOption(*opts),
+		WithPingTimeout(0),
+	)
 }
 
 // SetupManual with custom options
+//
+// Deprecated: use FromClient, the receiver of this method is ignored.
 func (i *Instance) SetupMongoByClient(client *mongo.Client, opts *option.MornOption) *Instance {
-	ins := Instance{
-		optField: opts,
-		logger:   opts.Logger,
+	if opts == nil {
+		opts = &option.MornOption{}
+	}
+	ins, err := FromClient(client, WithMornOption(*opts))
+	if err != nil {
+		return nil
 	}
-	ins.client = client
-	return &ins
+	return ins
 }
 
+// SetDB selects the database of the Instance
+// The Daos of the registered models are rebuilt on it, call Instance.Migrate to prepare their collections
 func (i *Instance) SetDB(db string) *Instance {
 	i.db = i.client.Database(db)
-	if i.optField.IsGenID {
+	if i.optField.IsGenID || i.modelsNeedGenerator() {
 		i.genDao = &Dao{
 			colName:    "generator",
 			template:   gen.Generator{},
@@ -72,11 +98,16 @@ func (i *Instance) SetDB(db string) *Instance {
 			logger:     i.GetLogger(),
 		}
 	}
+	if len(i.Models()) > 0 {
+		i.rebuildModels()
+	}
 	return i
 }
 
+// Disconnect closes the default client and the connections added with WithConnection
 func (i *Instance) Disconnect() error {
-	return i.client.Disconnect(context.TODO())
+	err := i.client.Disconnect(context.TODO())
+	return errors.Join(err, i.disconnectConnections(context.TODO()))
 }
 
 func (i *Instance) GetDB() *mongo.Database {
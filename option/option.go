@@ -1,7 +1,11 @@
 package option
 
 import (
+	"context"
+	"time"
+
 	"github.com/nghialthanh/morn-go/logger"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
@@ -9,7 +13,6 @@ import (
 )
 
 type MornOption struct {
-	otpMongo *options.ClientOptions
 	// generator config
 	// Create a new table named 'generator' to manage and control the incremental ID sequence for other collections in MongoDB
 	// The table will be created in the database when the setDatabase function of the instance is executed
@@ -22,6 +25,117 @@ type MornOption struct {
 	// field config
 	CreateAtField string
 	UpdateAtField string
+
+	// read/write config
+	// Applied to the collection of the Dao, Session transactions take precedence over them
+	ReadPreference *readpref.ReadPref
+	ReadConcern    *readconcern.ReadConcern
+	WriteConcern   *writeconcern.WriteConcern
+
+	// timeout config
+	// Default timeouts per operation kind, zero means no timeout. Clause.Timeout overrides them.
+	// The deadline is the earliest of the context deadline and the timeout, it is also sent to the server as maxTimeMS.
+	ReadTimeout      time.Duration
+	WriteTimeout     time.Duration
+	AggregateTimeout time.Duration
+	IndexTimeout     time.Duration
+
+	// retry config
+	// Retry applies to the operations of the Dao outside transactions, Clause.Retry overrides it. nil disables retries.
+	Retry *RetryPolicy
+
+	// resilience config
+	// Breaker stops sending operations of the Dao to a failing deployment, nil disables it
+	// MaxConcurrent limits the operations of the Dao running at once, zero means no limit.
+	// An operation waits up to MaxConcurrentWait for a free slot, zero fails right away.
+	Breaker           *BreakerOption
+	MaxConcurrent     int
+	MaxConcurrentWait time.Duration
+
+	// slow query config
+	// Operations slower than SlowQueryThreshold are logged with their query shape, sort, duration and caller,
+	// zero disables the log. ExplainSlowQueries also explains them in the background to log the winning plan.
+	SlowQueryThreshold time.Duration
+	ExplainSlowQueries bool
+
+	// audit config
+	// Audit records every document created, updated, replaced or deleted through the clauses of the Dao, nil disables it
+	Audit *AuditOption
+
+	// connection config
+	// Names of the Instance connections the Dao reads from, writes to and aggregates on (see Instance.AddConnection)
+	// Empty names use the default connection, AggregateConnection defaults to ReadConnection.
+	// Sessions are started on the write connection.
+	ReadConnection      string
+	WriteConnection     string
+	AggregateConnection string
+
+	// tenancy config
+	// SharedCollection keeps the collection of the Dao out of tenant isolation, it is shared by every tenant
+	SharedCollection bool
+}
+
+// ToCollectionOptions returns the collection options of the Dao or nil when none is set
+func (o *MornOption) ToCollectionOptions() *options.CollectionOptionsBuilder {
+	if o.ReadPreference == nil && o.ReadConcern == nil && o.WriteConcern == nil {
+		return nil
+	}
+	opts := options.Collection()
+	if o.ReadPreference != nil {
+		opts = opts.SetReadPreference(o.ReadPreference)
+	}
+	if o.ReadConcern != nil {
+		opts = opts.SetReadConcern(o.ReadConcern)
+	}
+	if o.WriteConcern != nil {
+		opts = opts.SetWriteConcern(o.WriteConcern)
+	}
+	return opts
+}
+
+// RetryPolicy retries operations failing with a transient error, such as a failover
+// The wait before attempt n+1 is InitialBackoff * Multiplier^(n-1), capped by MaxBackoff,
+// randomized between half and all of it. Zero values use the defaults below.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt, 1 disables retries (default 3)
+	MaxAttempts    int
+	InitialBackoff time.Duration // default 100ms
+	MaxBackoff     time.Duration // default 2s
+	Multiplier     float64       // default 2
+
+	// Retryable classifies the errors worth retrying, nil uses clause.Retryable
+	// (network errors, server selection failures, not-primary, shutdown and RetryableWriteError label)
+	Retryable func(err error) bool
+
+	// RetryNonIdempotent also retries inserts, single deletes and updates with operators other than
+	// $set, $unset, $setOnInsert, $min, $max and $addToSet, which may be applied twice
+	RetryNonIdempotent bool
+}
+
+// BreakerOption configures the circuit breaker of a Dao
+// The breaker opens when at least MinRequests operations ran in the current Window and the share of them
+// failing with a deployment error reaches FailureRate. After OpenTimeout it lets HalfOpenRequests operations through:
+// the breaker closes if they all succeed and opens again otherwise. Zero values use the defaults below.
+type BreakerOption struct {
+	FailureRate      float64       // default 0.5
+	MinRequests      int           // default 20
+	Window           time.Duration // default 10s
+	OpenTimeout      time.Duration // default 30s
+	HalfOpenRequests int           // default 3
+}
+
+// AuditOption configures the audit trail of a Dao
+// The records are written to Collection, in the database of the changed documents and, inside a Session,
+// in the same transaction. The single document writes capture the exact document they change, the documents
+// read before updateMany and deleteMany may miss concurrent changes outside of a transaction.
+type AuditOption struct {
+	// Collection receiving the audit records (default "audit_log")
+	Collection string
+	// MaxDocuments bounds the documents updateMany and deleteMany may change, bigger writes fail with
+	// morn.ErrAuditFailed before running (default 1000)
+	MaxDocuments int
+	// Actor returns who makes the change from the context of the operation, nil reads the actor set by morn.WithActor
+	Actor func(ctx context.Context) (string, bool)
 }
 
 type SessionOption struct {
@@ -43,3 +157,9 @@ func (o *SessionOption) ToTransactionOptions() *options.TransactionOptionsBuilde
 	}
 	return opts
 }
+
+type SnapshotOption struct {
+	// AtClusterTime pins the snapshot to a past cluster time, for example one returned by a previous Snapshot
+	// When nil the server picks the time of the first read
+	AtClusterTime *bson.Timestamp
+}
@@ -9,9 +9,9 @@ import (
 
 type QueryOption struct {
 	AllowPartialResults *bool              // Find, FindOne
-	Collation           *options.Collation // Find, FindOne, FindOneAndUpdate, DeleteOne, DeleteMany, Count, UpdateOne, UpdateMany, Aggregate, CreateIndex
-	Comment             interface{}        // Find, FindOne, FindOneAndUpdate, DeleteOne, DeleteMany, Count, UpdateOne, UpdateMany, InsertOne, InsertMany, Aggregate,
-	Hint                interface{}        // Find, FindOne, FindOneAndUpdate, DeleteOne, DeleteMany, Count, UpdateOne, UpdateMany, Aggregate,
+	Collation           *options.Collation // Find, FindOne, FindOneAndUpdate, DeleteOne, DeleteMany, Count, UpdateOne, UpdateMany, ReplaceOne, Aggregate, CreateIndex
+	Comment             interface{}        // Find, FindOne, FindOneAndUpdate, DeleteOne, DeleteMany, Count, UpdateOne, UpdateMany, ReplaceOne, InsertOne, InsertMany, Aggregate,
+	Hint                interface{}        // Find, FindOne, FindOneAndUpdate, DeleteOne, DeleteMany, Count, UpdateOne, UpdateMany, ReplaceOne, Aggregate,
 	Max                 interface{}        // Find, FindOne, CreateIndex(float64)
 	MaxAwaitTime        *time.Duration     // Find, Aggregate,
 	Min                 interface{}        // Find, FindOne, CreateIndex(float64)
@@ -20,18 +20,18 @@ type QueryOption struct {
 	ReturnKey           *bool              // Find, FindOne,
 	ShowRecordID        *bool              // Find, FindOne,
 	Skip                *int64             // Find, FindOne, Count,
-	Sort                interface{}        // Find, FindOne, UpdateOne, FindOneAndUpdate,
+	Sort                interface{}        // Find, FindOne, UpdateOne, ReplaceOne, FindOneAndUpdate,
 
 	AllowDiskUse    *bool               // Find, Aggregate,
 	BatchSize       *int32              // Find, Aggregate,
 	CursorType      *options.CursorType // Find,
-	Let             interface{}         // Find, DeleteOne, DeleteMany, UpdateOne, UpdateMany, Aggregate, FindOneAndUpdate,
+	Let             interface{}         // Find, DeleteOne, DeleteMany, UpdateOne, UpdateMany, ReplaceOne, Aggregate, FindOneAndUpdate,
 	Limit           *int64              // Find, Count,
 	NoCursorTimeout *bool               // Find,
 
 	ArrayFilters             []interface{} // UpdateOne, UpdateMany, FindOneAndUpdate,
-	BypassDocumentValidation *bool         // UpdateOne, UpdateMany, InsertOne, InsertMany, Aggregate, FindOneAndUpdate,
-	Upsert                   *bool         // UpdateOne, UpdateMany, FindOneAndUpdate,
+	BypassDocumentValidation *bool         // UpdateOne, UpdateMany, ReplaceOne, InsertOne, InsertMany, Aggregate, FindOneAndUpdate,
+	Upsert                   *bool         // UpdateOne, UpdateMany, ReplaceOne, FindOneAndUpdate,
 
 	Ordered *bool // InsertMany,
 
@@ -281,6 +281,32 @@ func (q *QueryOption) ToUpdateOne() *options.UpdateOneOptionsBuilder {
 	return opts
 }
 
+func (q *QueryOption) ToReplaceOne() *options.ReplaceOptionsBuilder {
+	opts := options.Replace()
+	if q.Collation != nil {
+		opts = opts.SetCollation(q.Collation)
+	}
+	if q.Comment != nil {
+		opts = opts.SetComment(q.Comment)
+	}
+	if q.Hint != nil {
+		opts = opts.SetHint(q.Hint)
+	}
+	if q.Sort != nil {
+		opts = opts.SetSort(q.Sort)
+	}
+	if q.Let != nil {
+		opts = opts.SetLet(q.Let)
+	}
+	if q.BypassDocumentValidation != nil {
+		opts = opts.SetBypassDocumentValidation(*q.BypassDocumentValidation)
+	}
+	if q.Upsert != nil {
+		opts = opts.SetUpsert(*q.Upsert)
+	}
+	return opts
+}
+
 func (q *QueryOption) ToUpdateMany() *options.UpdateManyOptionsBuilder {
 	opts := options.UpdateMany()
 	if q.Collation != nil {
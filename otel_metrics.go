@@ -0,0 +1,185 @@
+package morn
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// Attributes of the metrics that are not span attributes
+const (
+	attrErrorClass    = attribute.Key("morn.error.class")
+	attrServerAddress = attribute.Key("server.address")
+	attrPoolState     = attribute.Key("db.client.connection.state")
+	attrBreakerState  = attribute.Key("morn.breaker.state")
+)
+
+// OtelMetrics reports the Metrics of an Instance as OpenTelemetry instruments:
+//   - db.client.operation.duration, histogram of the operations in seconds
+//   - morn.documents.returned and morn.documents.modified, counters of documents
+//   - morn.operation.errors, counter of the failed operations by morn.error.class
+//   - morn.operation.retries, counter of the retries
+//   - morn.transactions, counter by outcome, and morn.transaction.duration in seconds
+//   - morn.genid.duration, histogram of the GenIDForDao allocations in seconds
+//   - morn.breaker.transitions, counter of the circuit breaker state changes by morn.breaker.state
+//   - db.client.connection.count by state and db.client.connection.max, gauges per server
+type OtelMetrics struct {
+	duration            metric.Float64Histogram
+	returned            metric.Int64Counter
+	modified            metric.Int64Counter
+	errors              metric.Int64Counter
+	retries             metric.Int64Counter
+	transactions        metric.Int64Counter
+	transactionDuration metric.Float64Histogram
+	genID               metric.Float64Histogram
+	breakerTransitions  metric.Int64Counter
+
+	meter metric.Meter
+}
+
+// NewOtelMetrics creates the instruments of OtelMetrics with the meters of provider
+func NewOtelMetrics(provider metric.MeterProvider) (*OtelMetrics, error) {
+	m := &OtelMetrics{meter: provider.Meter(tracerName)}
+	var err error
+	if m.duration, err = m.meter.Float64Histogram("db.client.operation.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of the database operations")); err != nil {
+		return nil, err
+	}
+	if m.returned, err = m.meter.Int64Counter("morn.documents.returned",
+		metric.WithUnit("{document}"), metric.WithDescription("Documents decoded from the results of reads")); err != nil {
+		return nil, err
+	}
+	if m.modified, err = m.meter.Int64Counter("morn.documents.modified",
+		metric.WithUnit("{document}"), metric.WithDescription("Documents inserted, updated or deleted")); err != nil {
+		return nil, err
+	}
+	if m.errors, err = m.meter.Int64Counter("morn.operation.errors",
+		metric.WithUnit("{operation}"), metric.WithDescription("Failed operations by error class")); err != nil {
+		return nil, err
+	}
+	if m.retries, err = m.meter.Int64Counter("morn.operation.retries",
+		metric.WithUnit("{retry}"), metric.WithDescription("Retries of failed operations")); err != nil {
+		return nil, err
+	}
+	if m.transactions, err = m.meter.Int64Counter("morn.transactions",
+		metric.WithUnit("{transaction}"), metric.WithDescription("Transactions by outcome")); err != nil {
+		return nil, err
+	}
+	if m.transactionDuration, err = m.meter.Float64Histogram("morn.transaction.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of the transactions")); err != nil {
+		return nil, err
+	}
+	if m.genID, err = m.meter.Float64Histogram("morn.genid.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of the GenIDForDao allocations")); err != nil {
+		return nil, err
+	}
+	if m.breakerTransitions, err = m.meter.Int64Counter("morn.breaker.transitions",
+		metric.WithUnit("{transition}"), metric.WithDescription("State changes of the circuit breakers by new state")); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewManualMetrics creates OtelMetrics backed by a manual reader, for applications without a collector
+// Metrics are read on demand with reader.Collect, for example from an HTTP handler.
+// Example:
+//
+//	metrics, reader, err := morn.NewManualMetrics()
+//	ins, err := morn.New(ctx, morn.WithURI(uri), morn.WithMetrics(metrics))
+//	var rm metricdata.ResourceMetrics
+//	err = reader.Collect(ctx, &rm)
+func NewManualMetrics() (*OtelMetrics, *sdkmetric.ManualReader, error) {
+	reader := sdkmetric.NewManualReader()
+	metrics, err := NewOtelMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return metrics, reader, nil
+}
+
+func (m *OtelMetrics) Operation(ctx context.Context, op OperationMetric) {
+	attrs := []attribute.KeyValue{
+		attrDBSystem.String("mongodb"),
+		attrDBCollection.String(op.Collection),
+		attrDBOperation.String(op.Operation),
+	}
+	set := metric.WithAttributes(attrs...)
+	if op.Err != nil {
+		class := ErrorClass(op.Err)
+		m.errors.Add(ctx, 1, metric.WithAttributes(append(attrs, attrErrorClass.String(class))...))
+		m.duration.Record(ctx, op.Duration.Seconds(), metric.WithAttributes(append(attrs, attrErrorType.String(class))...))
+	} else {
+		m.duration.Record(ctx, op.Duration.Seconds(), set)
+	}
+	if op.Returned > 0 {
+		m.returned.Add(ctx, op.Returned, set)
+	}
+	if op.Modified > 0 {
+		m.modified.Add(ctx, op.Modified, set)
+	}
+}
+
+func (m *OtelMetrics) Retry(ctx context.Context, collection string, operation string, attempt int, err error) {
+	m.retries.Add(ctx, 1, metric.WithAttributes(
+		attrDBCollection.String(collection),
+		attrDBOperation.String(operation),
+		attrErrorClass.String(ErrorClass(err)),
+	))
+}
+
+func (m *OtelMetrics) Transaction(ctx context.Context, collection string, committed bool, duration time.Duration) {
+	outcome := "aborted"
+	if committed {
+		outcome = "committed"
+	}
+	set := metric.WithAttributes(attrDBCollection.String(collection), attrOutcome.String(outcome))
+	m.transactions.Add(ctx, 1, set)
+	m.transactionDuration.Record(ctx, duration.Seconds(), set)
+}
+
+func (m *OtelMetrics) GenID(ctx context.Context, collection string, duration time.Duration, err error) {
+	attrs := []attribute.KeyValue{attrSequence.String(collection)}
+	if err != nil {
+		attrs = append(attrs, attrErrorType.String(ErrorClass(err)))
+	}
+	m.genID.Record(ctx, duration.Seconds(), metric.WithAttributes(attrs...))
+}
+
+func (m *OtelMetrics) Breaker(event BreakerEvent) {
+	m.breakerTransitions.Add(context.Background(), 1, metric.WithAttributes(
+		attrDBCollection.String(event.Collection),
+		attrBreakerState.String(event.To.String()),
+	))
+}
+
+// ObservePools registers the connection pool gauges, read is called on every collection until stop is called
+func (m *OtelMetrics) ObservePools(read func() []PoolHealth) (func() error, error) {
+	count, err := m.meter.Int64ObservableGauge("db.client.connection.count",
+		metric.WithUnit("{connection}"), metric.WithDescription("Open connections by state"))
+	if err != nil {
+		return nil, err
+	}
+	max, err := m.meter.Int64ObservableGauge("db.client.connection.max",
+		metric.WithUnit("{connection}"), metric.WithDescription("Maximum size of the connection pools"))
+	if err != nil {
+		return nil, err
+	}
+	registration, err := m.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for _, pool := range read() {
+			address := attrServerAddress.String(pool.Address)
+			o.ObserveInt64(count, pool.InUse, metric.WithAttributes(address, attrPoolState.String("used")))
+			o.ObserveInt64(count, pool.Open-pool.InUse, metric.WithAttributes(address, attrPoolState.String("idle")))
+			if pool.Max > 0 {
+				o.ObserveInt64(max, int64(pool.Max), metric.WithAttributes(address))
+			}
+		}
+		return nil
+	}, count, max)
+	if err != nil {
+		return nil, err
+	}
+	return registration.Unregister, nil
+}
@@ -0,0 +1,238 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Model describes a collection managed by the Instance, see Instance.Register
+type Model struct {
+	Collection string
+	// Template is the struct documents are decoded into, User{} or &User{}
+	Template interface{}
+	// Option overrides the MornOption of the Instance for this model
+	Option *option.MornOption
+	// Indexes are declared with those of the morn tags of Template, see Dao.Indexes
+	Indexes []Index
+}
+
+// Index is an index created by Instance.Migrate and Dao.SyncIndexes
+// Keys use the format of Clause.CreateIndex, for example []string{"username:1", "email:1"},
+// the value of a key may also be an index type, for example "title:text".
+type Index struct {
+	Keys   []string
+	Option option.QueryOption
+}
+
+type registered struct {
+	model Model
+	dao   *Dao
+}
+
+// Register records models so their Dao is built and their collection prepared by the Instance
+// Daos are built as soon as the database is set. Sequences, collections and indexes are created
+// by Migrate, which must be called once the database is set.
+// Example:
+//
+//	ins.Register(morn.Model{
+//		Collection: "users",
+//		Template:   User{},
+//		Indexes:    []morn.Index{{Keys: []string{"user_id:1"}, Option: option.QueryOption{Unique: &unique}}},
+//	})
+//	if err := ins.Migrate(ctx); err != nil {
+//		return err
+//	}
+//	userDao := morn.DaoOf[User](ins)
+func (i *Instance) Register(models ...Model) error {
+	i.registryMu.Lock()
+	defer i.registryMu.Unlock()
+	if i.models == nil {
+		i.models = make(map[string]*registered)
+		i.modelTypes = make(map[reflect.Type]string)
+	}
+
+	for _, model := range models {
+		if model.Collection == "" {
+			return errors.New("morn: Register: collection name is empty")
+		}
+		if model.Template == nil {
+			return fmt.Errorf("morn: Register %s: template is nil", model.Collection)
+		}
+		if _, ok := i.models[model.Collection]; ok {
+			return fmt.Errorf("morn: Register: collection %s is already registered", model.Collection)
+		}
+		typ := modelType(reflect.TypeOf(model.Template))
+		if name, ok := i.modelTypes[typ]; ok {
+			return fmt.Errorf("morn: Register %s: type %s is already registered for collection %s", model.Collection, typ, name)
+		}
+
+		entry := &registered{model: model}
+		if i.db != nil {
+			entry.dao = i.buildDao(model)
+		}
+		i.models[model.Collection] = entry
+		i.modelTypes[typ] = model.Collection
+	}
+	return nil
+}
+
+// Models returns the registered models sorted by collection name
+func (i *Instance) Models() []Model {
+	i.registryMu.RLock()
+	defer i.registryMu.RUnlock()
+	models := make([]Model, 0, len(i.models))
+	for _, entry := range i.models {
+		models = append(models, entry.model)
+	}
+	sort.Slice(models, func(a, b int) bool { return models[a].Collection < models[b].Collection })
+	return models
+}
+
+// DaoByName returns the Dao of the model registered for collection
+// nil is returned when no model is registered for it or the database is not set yet
+func (i *Instance) DaoByName(collection string) *Dao {
+	i.registryMu.RLock()
+	defer i.registryMu.RUnlock()
+	entry, ok := i.models[collection]
+	if !ok {
+		return nil
+	}
+	return entry.dao
+}
+
+// DaoOf returns the Dao of the model registered with a template of type T
+// nil is returned when no model is registered for T or the database is not set yet
+func DaoOf[T any](ins *Instance) *Dao {
+	typ := modelType(reflect.TypeOf((*T)(nil)).Elem())
+	ins.registryMu.RLock()
+	name, ok := ins.modelTypes[typ]
+	ins.registryMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return ins.DaoByName(name)
+}
+
+// Migrate creates the sequences, collections and indexes of every registered model
+// Existing collections and indexes are kept, indexes that differ from their declaration are logged, see Dao.SyncIndexes. With tenant isolation the collections of the tenant of ctx are prepared.
+// All models are migrated, the returned error joins the failures.
+func (i *Instance) Migrate(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	if i.db == nil {
+		return fmt.Errorf("%w, call SetDB first", ErrNoDatabase)
+	}
+
+	var errs []error
+	for _, model := range i.Models() {
+		dao := i.DaoByName(model.Collection)
+		if dao == nil {
+			continue
+		}
+		if err := i.migrate(ctx, dao, model); err != nil {
+			errs = append(errs, fmt.Errorf("morn: Migrate %s: %w", model.Collection, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (i *Instance) migrate(ctx context.Context, dao *Dao, model Model) error {
+	if dao.option.IsGenID {
+		if i.genDao == nil {
+			return errors.New("generator dao not found, enable IsGenID on the Instance")
+		}
+		_, err := i.genDao.collection.UpdateOne(ctx,
+			bson.M{"_id": model.Collection},
+			bson.M{"$setOnInsert": bson.M{"value": dao.option.DefaultNumber}},
+			options.UpdateOne().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("create sequence: %w", err)
+		}
+	}
+
+	collection, err := dao.writeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	names, err := collection.Database().ListCollectionNames(ctx, bson.M{"name": collection.Name()})
+	if err != nil {
+		return fmt.Errorf("list collections: %w", err)
+	}
+	if len(names) == 0 {
+		if err := collection.Database().CreateCollection(ctx, collection.Name()); err != nil && !isNamespaceExists(err) {
+			return fmt.Errorf("create collection: %w", err)
+		}
+	}
+
+	plan, err := dao.SyncIndexes(ctx, SyncCreate)
+	if err != nil {
+		return err
+	}
+	for _, m := range plan.Mismatched {
+		i.GetLogger().Warnf("Collection %s: index %s: %s is %s, declared %s", model.Collection, m.Name, m.Option, m.Existing, m.Declared)
+	}
+	return nil
+}
+
+func (i *Instance) modelsNeedGenerator() bool {
+	for _, model := range i.Models() {
+		if model.Option != nil && model.Option.IsGenID {
+			return true
+		}
+	}
+	return false
+}
+
+// buildDao creates the Dao of model without touching the database
+func (i *Instance) buildDao(model Model) *Dao {
+	opt := i.GetOptsField()
+	if model.Option != nil {
+		opt = *model.Option
+	}
+	return newDao(model.Collection, model.Template, i, opt).Index(model.Indexes...)
+}
+
+// rebuildModels recreates the Daos of the registered models after the database changed
+func (i *Instance) rebuildModels() {
+	i.registryMu.Lock()
+	defer i.registryMu.Unlock()
+	for _, entry := range i.models {
+		entry.dao = i.buildDao(entry.model)
+	}
+}
+
+// writeCollection returns the collection the Dao writes to for the tenant of ctx
+func (d *Dao) writeCollection(ctx context.Context) (*mongo.Collection, error) {
+	if !d.bound() {
+		collection, _, err := d.resolve(ctx)
+		return collection, err
+	}
+	routes, _, err := d.routes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return routes[clause.KindWrite][0], nil
+}
+
+func modelType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+func isNamespaceExists(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 48
+}
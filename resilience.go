@@ -0,0 +1,306 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/logger"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrCircuitOpen  = errors.New("morn: circuit breaker is open")
+	ErrBulkheadFull = errors.New("morn: too many concurrent operations")
+)
+
+const (
+	defaultBreakerFailureRate = 0.5
+	defaultBreakerMinRequests = 20
+	defaultBreakerWindow      = 10 * time.Second
+	defaultBreakerOpenTimeout = 30 * time.Second
+	defaultBreakerProbes      = 3
+)
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerEvent is a state change of the circuit breaker of a Dao
+// FailureRate is the rate of the window that opened the breaker
+type BreakerEvent struct {
+	Collection  string
+	From        BreakerState
+	To          BreakerState
+	FailureRate float64
+	Time        time.Time
+}
+
+// OnBreakerStateChange registers fn to be called when the circuit breaker of a Dao changes state
+// Changes are also logged through the logger of the Instance and reported to its Metrics. fn must not block.
+func (i *Instance) OnBreakerStateChange(fn func(BreakerEvent)) *Instance {
+	i.hooksMu.Lock()
+	i.breakerHooks = append(i.breakerHooks, fn)
+	i.hooksMu.Unlock()
+	return i
+}
+
+func (i *Instance) emitBreakerEvent(e BreakerEvent) {
+	switch e.To {
+	case BreakerOpen:
+		i.logger.Warnf("Circuit breaker of %s opened (failure rate %.2f)", e.Collection, e.FailureRate)
+	case BreakerHalfOpen:
+		i.logger.Infof("Circuit breaker of %s is half-open, probing", e.Collection)
+	case BreakerClosed:
+		i.logger.Infof("Circuit breaker of %s closed", e.Collection)
+	}
+
+	if metrics := i.currentMetrics(); metrics != nil {
+		metrics.Breaker(e)
+	}
+
+	i.hooksMu.RLock()
+	hooks := i.breakerHooks
+	i.hooksMu.RUnlock()
+	for _, hook := range hooks {
+		hook(e)
+	}
+}
+
+// BreakerState returns the state of the circuit breaker of the Dao, BreakerClosed when it has none
+func (d *Dao) BreakerState() BreakerState {
+	if d.breaker == nil {
+		return BreakerClosed
+	}
+	d.breaker.mu.Lock()
+	defer d.breaker.mu.Unlock()
+	return d.breaker.state
+}
+
+// resilienceGates returns the circuit breaker and bulkhead configured by opt, breaker first so it fails fast
+func (d *Dao) resilienceGates() []clause.Gate {
+	var gates []clause.Gate
+	if d.breaker != nil {
+		gates = append(gates, d.breaker)
+	}
+	if d.bulkhead != nil {
+		gates = append(gates, d.bulkhead)
+	}
+	return gates
+}
+
+// ---------------------------------- breaker ----------------------------------//
+
+// breaker is the circuit breaker of a Dao, it counts outcomes in fixed windows
+type breaker struct {
+	name   string
+	opt    option.BreakerOption
+	notify func(BreakerEvent)
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+func newBreaker(name string, opt option.BreakerOption, notify func(BreakerEvent)) *breaker {
+	if opt.FailureRate <= 0 || opt.FailureRate > 1 {
+		opt.FailureRate = defaultBreakerFailureRate
+	}
+	if opt.MinRequests <= 0 {
+		opt.MinRequests = defaultBreakerMinRequests
+	}
+	if opt.Window <= 0 {
+		opt.Window = defaultBreakerWindow
+	}
+	if opt.OpenTimeout <= 0 {
+		opt.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if opt.HalfOpenRequests <= 0 {
+		opt.HalfOpenRequests = defaultBreakerProbes
+	}
+	return &breaker{name: name, opt: opt, notify: notify, windowStart: time.Now()}
+}
+
+// Admit rejects operations with ErrCircuitOpen while the breaker is open
+// or when the probes of the half-open state are already running
+func (b *breaker) Admit(ctx context.Context, kind clause.OpKind) (func(err error), error) {
+	b.mu.Lock()
+	var events []BreakerEvent
+	if b.state == BreakerOpen {
+		if time.Since(b.openedAt) < b.opt.OpenTimeout {
+			b.mu.Unlock()
+			return nil, fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+		}
+		events = append(events, b.transition(BreakerHalfOpen, 0))
+		b.probes, b.successes = 0, 0
+	}
+	probe := b.state == BreakerHalfOpen
+	if probe {
+		if b.probes >= b.opt.HalfOpenRequests {
+			b.mu.Unlock()
+			b.emit(events)
+			return nil, fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+		}
+		b.probes++
+	}
+	b.mu.Unlock()
+	b.emit(events)
+
+	return func(err error) { b.record(err, probe) }, nil
+}
+
+func (b *breaker) record(err error, probe bool) {
+	failed := breakerFailure(err)
+
+	b.mu.Lock()
+	var events []BreakerEvent
+	switch {
+	case errors.Is(err, ErrBulkheadFull):
+		// rejected by the bulkhead, the operation never reached the deployment
+		if probe && b.state == BreakerHalfOpen && b.probes > 0 {
+			b.probes--
+		}
+	case b.state == BreakerClosed && !probe:
+		now := time.Now()
+		if now.Sub(b.windowStart) >= b.opt.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		rate := float64(b.failures) / float64(b.requests)
+		if b.requests >= b.opt.MinRequests && rate >= b.opt.FailureRate {
+			events = append(events, b.transition(BreakerOpen, rate))
+		}
+	case b.state == BreakerHalfOpen && probe:
+		if failed {
+			events = append(events, b.transition(BreakerOpen, 1))
+			break
+		}
+		b.successes++
+		if b.successes >= b.opt.HalfOpenRequests {
+			events = append(events, b.transition(BreakerClosed, 0))
+		}
+	}
+	b.mu.Unlock()
+	b.emit(events)
+}
+
+// transition must be called with mu held
+func (b *breaker) transition(to BreakerState, rate float64) BreakerEvent {
+	event := BreakerEvent{Collection: b.name, From: b.state, To: to, FailureRate: rate, Time: time.Now()}
+	b.state = to
+	switch to {
+	case BreakerOpen:
+		b.openedAt = event.Time
+	case BreakerClosed:
+		b.windowStart, b.requests, b.failures = event.Time, 0, 0
+	}
+	return event
+}
+
+func (b *breaker) emit(events []BreakerEvent) {
+	if b.notify == nil {
+		return
+	}
+	for _, event := range events {
+		b.notify(event)
+	}
+}
+
+// breakerFailure reports whether err means the deployment is degraded
+// Errors caused by the request itself, such as a duplicate key or a cancelled context, do not count.
+func breakerFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return clause.Retryable(err) || mongo.IsTimeout(err)
+}
+
+// ---------------------------------- bulkhead ----------------------------------//
+
+// bulkhead limits the operations of a Dao running at once
+type bulkhead struct {
+	name   string
+	slots  chan struct{}
+	wait   time.Duration
+	logger logger.ILogger
+
+	mu        sync.Mutex
+	saturated bool
+}
+
+func newBulkhead(name string, limit int, wait time.Duration, log logger.ILogger) *bulkhead {
+	return &bulkhead{name: name, slots: make(chan struct{}, limit), wait: wait, logger: log}
+}
+
+// Admit takes a slot, waiting up to the configured wait, and rejects the operation with ErrBulkheadFull otherwise
+// When ctx ends while waiting the error wraps both ErrBulkheadFull and the error of ctx.
+func (b *bulkhead) Admit(ctx context.Context, kind clause.OpKind) (func(err error), error) {
+	select {
+	case b.slots <- struct{}{}:
+		b.setSaturated(false)
+		return b.release, nil
+	default:
+	}
+
+	if b.wait > 0 {
+		timer := time.NewTimer(b.wait)
+		defer timer.Stop()
+		select {
+		case b.slots <- struct{}{}:
+			return b.release, nil
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s: %w: %w", b.name, ErrBulkheadFull, ctx.Err())
+		case <-timer.C:
+		}
+	}
+	b.setSaturated(true)
+	return nil, fmt.Errorf("%s: %w (limit %d)", b.name, ErrBulkheadFull, cap(b.slots))
+}
+
+func (b *bulkhead) release(err error) {
+	<-b.slots
+}
+
+// setSaturated logs when the bulkhead starts and stops rejecting operations
+func (b *bulkhead) setSaturated(saturated bool) {
+	b.mu.Lock()
+	changed := b.saturated != saturated
+	b.saturated = saturated
+	b.mu.Unlock()
+	if !changed {
+		return
+	}
+	if saturated {
+		b.logger.Warnf("Bulkhead of %s is full (limit %d), rejecting operations", b.name, cap(b.slots))
+	} else {
+		b.logger.Infof("Bulkhead of %s accepts operations again", b.name)
+	}
+}
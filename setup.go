@@ -0,0 +1,257 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nghialthanh/morn-go/logger"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const defaultPingTimeout = 10 * time.Second
+
+// Option configures an Instance built by New or FromClient
+type Option func(*setupConfig) error
+
+type setupConfig struct {
+	uri           string
+	clientOptions []*options.ClientOptions
+	serverAPI     *options.ServerAPIOptions
+	logger        logger.ILogger
+	database      string
+	mornOption    option.MornOption
+	pingTimeout   time.Duration
+	skipPing      bool
+
+	tenancy        TenantStrategy
+	tenantResolver TenantResolver
+	connections    []connectionSpec
+	tracerProvider trace.TracerProvider
+	metrics        Metrics
+}
+
+// WithURI sets the connection string used by New
+func WithURI(uri string) Option {
+	return func(c *setupConfig) error {
+		if uri == "" {
+			return errors.New("morn: WithURI: uri is empty")
+		}
+		c.uri = uri
+		return nil
+	}
+}
+
+// WithClientOptions adds driver client options, they are applied after the URI so they take precedence over it
+func WithClientOptions(opts ...*options.ClientOptions) Option {
+	return func(c *setupConfig) error {
+		for _, opt := range opts {
+			if opt == nil {
+				return errors.New("morn: WithClientOptions: nil client options")
+			}
+		}
+		c.clientOptions = append(c.clientOptions, opts...)
+		return nil
+	}
+}
+
+// WithServerAPI pins the Stable API version, New does not set one unless asked
+func WithServerAPI(version options.ServerAPIVersion) Option {
+	return func(c *setupConfig) error {
+		c.serverAPI = options.ServerAPI(version)
+		return nil
+	}
+}
+
+// WithLogger sets the logger of the Instance, it overrides MornOption.Logger
+func WithLogger(l logger.ILogger) Option {
+	return func(c *setupConfig) error {
+		if l == nil {
+			return errors.New("morn: WithLogger: logger is nil")
+		}
+		c.logger = l
+		return nil
+	}
+}
+
+// WithDatabase selects the database, same as calling SetDB after construction
+func WithDatabase(name string) Option {
+	return func(c *setupConfig) error {
+		if name == "" {
+			return errors.New("morn: WithDatabase: database name is empty")
+		}
+		c.database = name
+		return nil
+	}
+}
+
+// WithMornOption sets the default options inherited by every Dao of the Instance
+func WithMornOption(opt option.MornOption) Option {
+	return func(c *setupConfig) error {
+		if opt.IsGenID && opt.DefaultNumber < 0 {
+			return fmt.Errorf("morn: WithMornOption: DefaultNumber must not be negative, got %d", opt.DefaultNumber)
+		}
+		c.mornOption = opt
+		return nil
+	}
+}
+
+// WithPingTimeout bounds the ping New sends after connecting, zero disables the ping
+func WithPingTimeout(d time.Duration) Option {
+	return func(c *setupConfig) error {
+		if d < 0 {
+			return fmt.Errorf("morn: WithPingTimeout: negative timeout %s", d)
+		}
+		c.pingTimeout = d
+		c.skipPing = d == 0
+		return nil
+	}
+}
+
+func newSetupConfig(opts []Option) (*setupConfig, error) {
+	cfg := &setupConfig{pingTimeout: defaultPingTimeout}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.logger == nil {
+		cfg.logger = cfg.mornOption.Logger
+	}
+	if cfg.logger == nil {
+		cfg.logger = logger.NewFmtLogger()
+	}
+	cfg.mornOption.Logger = cfg.logger
+	return cfg, nil
+}
+
+// New connects a client and returns a ready Instance
+// The configuration is validated before connecting and the primary is pinged (10s by default, see WithPingTimeout),
+// so a returned Instance is known to reach the deployment.
+// Example:
+//
+//	ins, err := morn.New(ctx,
+//		morn.WithURI("mongodb://localhost:27017"),
+//		morn.WithDatabase("app"),
+//		morn.WithMornOption(option.MornOption{IsGenID: true, DefaultNumber: 100000}),
+//	)
+func New(ctx context.Context, opts ...Option) (*Instance, error) {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	cfg, err := newSetupConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOpts := options.Client()
+	if cfg.uri != "" {
+		clientOpts.ApplyURI(cfg.uri)
+	}
+	merged := options.MergeClientOptions(append([]*options.ClientOptions{clientOpts}, cfg.clientOptions...)...)
+	if cfg.serverAPI != nil {
+		merged.SetServerAPIOptions(cfg.serverAPI)
+	}
+	if cfg.uri == "" && len(merged.Hosts) == 0 {
+		return nil, errors.New("morn: New: no deployment configured, use WithURI or WithClientOptions with hosts")
+	}
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("morn: New: invalid client options: %w", err)
+	}
+	mon := newMonitor(cfg.logger)
+	mon.install(merged)
+
+	client, err := mongo.Connect(merged)
+	if err != nil {
+		cfg.logger.Error("Failed to connect to MongoDB", err)
+		return nil, fmt.Errorf("morn: New: connect: %w", err)
+	}
+
+	if !cfg.skipPing {
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.pingTimeout)
+		err = client.Ping(pingCtx, readpref.Primary())
+		cancel()
+		if err != nil {
+			_ = client.Disconnect(context.WithoutCancel(ctx))
+			cfg.logger.Error("Failed to ping MongoDB", err)
+			return nil, fmt.Errorf("morn: New: ping primary within %s: %w", cfg.pingTimeout, err)
+		}
+	}
+
+	ins := newInstance(client, cfg)
+	ins.monitor = mon
+	if cfg.metrics != nil {
+		if err := ins.SetMetrics(cfg.metrics); err != nil {
+			_ = ins.Disconnect()
+			return nil, fmt.Errorf("morn: New: metrics: %w", err)
+		}
+	}
+	for _, spec := range cfg.connections {
+		conn, err := spec.connect(ctx, cfg)
+		if err == nil {
+			err = ins.addConnection(spec.name, conn)
+		}
+		if err != nil {
+			cfg.logger.Error("Failed to connect to MongoDB", err)
+			if conn != nil {
+				_ = conn.db.Client().Disconnect(context.WithoutCancel(ctx))
+			}
+			_ = ins.Disconnect()
+			return nil, err
+		}
+	}
+	return ins, nil
+}
+
+// FromClient wraps a client that is already connected and managed by the caller
+// The client is not pinged, the caller is expected to have checked it
+func FromClient(client *mongo.Client, opts ...Option) (*Instance, error) {
+	if client == nil {
+		return nil, errors.New("morn: FromClient: client is nil")
+	}
+	cfg, err := newSetupConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.uri != "" || len(cfg.clientOptions) > 0 || cfg.serverAPI != nil {
+		return nil, errors.New("morn: FromClient: connection options cannot be applied to an existing client")
+	}
+	if len(cfg.connections) > 0 {
+		return nil, errors.New("morn: FromClient: WithConnection requires New, use Instance.AddConnection with a connected client")
+	}
+	ins := newInstance(client, cfg)
+	if cfg.metrics != nil {
+		if err := ins.SetMetrics(cfg.metrics); err != nil {
+			return nil, fmt.Errorf("morn: FromClient: metrics: %w", err)
+		}
+	}
+	return ins, nil
+}
+
+func newInstance(client *mongo.Client, cfg *setupConfig) *Instance {
+	mornOption := cfg.mornOption
+	ins := &Instance{
+		client:   client,
+		optField: &mornOption,
+		logger:   cfg.logger,
+		tracker:  newTracker(),
+
+		tracerProvider: cfg.tracerProvider,
+	}
+	if cfg.tenancy != nil {
+		ins.SetTenancy(cfg.tenancy, cfg.tenantResolver)
+	}
+	if cfg.database != "" {
+		ins.SetDB(cfg.database)
+	}
+	return ins
+}
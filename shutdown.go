@@ -0,0 +1,201 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrShuttingDown = errors.New("morn: instance is shutting down")
+
+const abortTimeout = 5 * time.Second
+
+// ShutdownReport describes what Instance.Shutdown drained and what it had to cancel
+type ShutdownReport struct {
+	DrainedOperations   int64
+	DrainedSessions     int
+	CancelledOperations int64
+	AbortedTransactions int
+	Duration            time.Duration
+}
+
+// tracker counts the operations and sessions running on an Instance
+// It is the clause.Gate attached to every Dao of the Instance.
+type tracker struct {
+	mu       sync.Mutex
+	closing  bool
+	ops      int64
+	sessions map[*mongo.Session]*trackedSession
+	aborted  int
+	idle     chan struct{}
+	// sessionsIdle is closed once the last session ended during Shutdown
+	sessionsIdle chan struct{}
+}
+
+// trackedSession is a session registered by its owner, cancel cancels the context the owner runs it with
+type trackedSession struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+func newTracker() *tracker {
+	return &tracker{sessions: make(map[*mongo.Session]*trackedSession)}
+}
+
+// Admit rejects new operations once Shutdown started
+// Operations of a Session opened before are still admitted so the Session can finish.
+func (t *tracker) Admit(ctx context.Context, kind clause.OpKind) (func(err error), error) {
+	if err := t.enter(mongo.SessionFromContext(ctx)); err != nil {
+		return nil, err
+	}
+	return func(err error) { t.leave() }, nil
+}
+
+func (t *tracker) enter(session *mongo.Session) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		if _, open := t.sessions[session]; session == nil || !open {
+			return ErrShuttingDown
+		}
+	}
+	t.ops++
+	return nil
+}
+
+func (t *tracker) leave() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ops--
+	t.notifyIdle()
+}
+
+func (t *tracker) openSession(session *mongo.Session, cancel context.CancelFunc) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		return ErrShuttingDown
+	}
+	t.sessions[session] = &trackedSession{cancel: cancel}
+	return nil
+}
+
+// closeSession unregisters session, aborted reports that its owner aborted a transaction
+func (t *tracker) closeSession(session *mongo.Session, aborted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tracked, ok := t.sessions[session]; ok && tracked.cancelled && aborted {
+		t.aborted++
+	}
+	delete(t.sessions, session)
+	t.notifyIdle()
+}
+
+// cancelSessions cancels the contexts of the sessions still open and returns how many there are
+func (t *tracker) cancelSessions() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tracked := range t.sessions {
+		tracked.cancelled = true
+		tracked.cancel()
+	}
+	return len(t.sessions)
+}
+
+// notifyIdle must be called with mu held
+func (t *tracker) notifyIdle() {
+	if !t.closing || len(t.sessions) > 0 {
+		return
+	}
+	if t.sessionsIdle != nil {
+		close(t.sessionsIdle)
+		t.sessionsIdle = nil
+	}
+	if t.ops == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// Shutdown stops the Instance gracefully
+// New Clause operations and Sessions fail with ErrShuttingDown right away, then Shutdown waits
+// for the operations and Sessions in flight until ctx is done. Sessions opened before keep running their operations.
+// At that point the contexts of the Sessions still open are cancelled, so that Dao.Session aborts its transaction,
+// and after waiting for them up to 5 seconds the client is disconnected, which cancels the remaining operations.
+func (i *Instance) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+	start := time.Now()
+	t := i.tracker
+
+	t.mu.Lock()
+	if t.closing {
+		t.mu.Unlock()
+		return nil, ErrShuttingDown
+	}
+	t.closing = true
+	idle, sessionsIdle := make(chan struct{}), make(chan struct{})
+	t.idle, t.sessionsIdle = idle, sessionsIdle
+	startOps, startSessions := t.ops, len(t.sessions)
+	t.notifyIdle()
+	t.mu.Unlock()
+
+	i.logger.Infof("Shutting down: draining %d operations and %d sessions", startOps, startSessions)
+
+	select {
+	case <-idle:
+	case <-ctx.Done():
+	}
+
+	t.mu.Lock()
+	remainingOps := t.ops
+	t.mu.Unlock()
+	remaining := t.cancelSessions()
+	if remaining > 0 {
+		select {
+		case <-sessionsIdle:
+		case <-time.After(abortTimeout):
+			i.logger.Warnf("Shutting down: %d sessions did not end after their context was cancelled", remaining)
+		}
+	}
+	t.mu.Lock()
+	report := &ShutdownReport{
+		DrainedOperations:   startOps - remainingOps,
+		DrainedSessions:     startSessions - remaining,
+		CancelledOperations: remainingOps,
+		AbortedTransactions: t.aborted,
+	}
+	t.mu.Unlock()
+	if report.DrainedSessions < 0 {
+		report.DrainedSessions = 0
+	}
+
+	err := errors.Join(i.client.Disconnect(context.WithoutCancel(ctx)), i.disconnectConnections(context.WithoutCancel(ctx)))
+	report.Duration = time.Since(start)
+	i.logger.Infof("Shutdown finished in %s: drained %d operations and %d sessions, cancelled %d operations, aborted %d transactions",
+		report.Duration, report.DrainedOperations, report.DrainedSessions, report.CancelledOperations, report.AbortedTransactions)
+	return report, err
+}
+
+// trackSession registers session so Shutdown waits for it
+// The session must be run with the returned context, which Shutdown cancels once its drain timeout expired.
+// release must be called when the session ends, aborted reports that its transaction was aborted.
+func (i *Instance) trackSession(ctx context.Context, session *mongo.Session) (context.Context, func(aborted bool), error) {
+	if i == nil || i.tracker == nil {
+		return ctx, func(bool) {}, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	if err := i.tracker.openSession(session, cancel); err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return ctx, func(aborted bool) {
+		i.tracker.closeSession(session, aborted)
+		cancel()
+	}, nil
+}
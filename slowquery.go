@@ -0,0 +1,165 @@
+package morn
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	explainTimeout = 10 * time.Second
+	// explainInterval is the minimum time between two explains of the same query shape
+	explainInterval = time.Minute
+)
+
+// internalPackages are skipped when looking for the caller of a slow query
+var internalPackages = map[string]bool{
+	tracerName:             true,
+	tracerName + "/clause": true,
+	tracerName + "/option": true,
+	tracerName + "/utils":  true,
+	tracerName + "/gen":    true,
+	tracerName + "/logger": true,
+	tracerName + "/config": true,
+}
+
+// explainer runs the explains of slow queries, at most one per query shape and explainInterval
+type explainer struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func (e *explainer) allow(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.last == nil {
+		e.last = make(map[string]time.Time)
+	}
+	now := time.Now()
+	if last, ok := e.last[key]; ok && now.Sub(last) < explainInterval {
+		return false
+	}
+	e.last[key] = now
+	return true
+}
+
+// slowQueryLog is the interceptor logging the operations of the Dao slower than MornOption.SlowQueryThreshold
+// With MornOption.ExplainSlowQueries the query is explained in the background and its plan logged too.
+func (d *Dao) slowQueryLog(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+	start := time.Now()
+	err := next(ctx, op)
+	elapsed := time.Since(start)
+	if elapsed < d.option.SlowQueryThreshold {
+		return err
+	}
+
+	caller := callerLocation()
+	shape := op.QueryShape()
+	sort := ""
+	if s := op.Sort(); s != nil {
+		if data, marshalErr := bson.MarshalExtJSON(s, false, false); marshalErr == nil {
+			sort = string(data)
+		}
+	}
+	args := []interface{}{
+		"collection", op.Collection.Name(),
+		"operation", op.Name,
+		"query", shape,
+		"sort", sort,
+		"duration", elapsed,
+		"caller", caller,
+	}
+	if err != nil {
+		args = append(args, "error", err)
+	}
+	d.logger.Warn("Slow query", args...)
+
+	if d.option.ExplainSlowQueries && d.ins != nil && explainable(op.Name) &&
+		d.ins.explainer.allow(op.Collection.Name()+" "+op.Name+" "+shape+" "+sort) {
+		// the command is marshalled here, the goroutine must not read the documents of the caller
+		if cmd, cmdErr := explainCommand(op); cmdErr != nil {
+			d.logger.Debugf("Slow query on %s not explained: %v", op.Collection.Name(), cmdErr)
+		} else {
+			go d.explainSlowQuery(op.Collection, op.Name, cmd, caller)
+		}
+	}
+	return err
+}
+
+// explainCommand returns the explain command of op, marshalled
+func explainCommand(op *clause.Operation) (bson.Raw, error) {
+	cmd, err := op.Command()
+	if err != nil {
+		return nil, err
+	}
+	return bson.Marshal(bson.D{
+		{Key: "explain", Value: cmd},
+		{Key: "verbosity", Value: string(clause.ExecutionStats)},
+	})
+}
+
+// explainSlowQuery runs the explain command cmd outside of the session of the query and logs the winning plan
+func (d *Dao) explainSlowQuery(collection *mongo.Collection, operation string, cmd bson.Raw, caller string) {
+	ctx, cancel := context.WithTimeout(context.Background(), explainTimeout)
+	defer cancel()
+
+	var explain bson.M
+	err := collection.Database().RunCommand(ctx, cmd).Decode(&explain)
+	if err != nil {
+		d.logger.Warnf("Failed to explain slow query on %s: %v", collection.Name(), err)
+		return
+	}
+
+	res := clause.ParseExplain(explain)
+	d.logger.Warn("Slow query plan",
+		"collection", collection.Name(),
+		"operation", operation,
+		"caller", caller,
+		"plan", res.Plan.String(),
+		"docs_examined", res.DocsExamined,
+		"keys_examined", res.KeysExamined,
+		"returned", res.Returned,
+		"docs_examined_ratio", res.DocsExaminedRatio(),
+	)
+}
+
+func explainable(name string) bool {
+	switch name {
+	case "find", "findOne", "countDocuments", "aggregate", "updateOne", "updateMany", "replaceOne", "findOneAndUpdate", "deleteOne", "deleteMany":
+		return true
+	}
+	return false
+}
+
+// callerLocation returns the file and line of the first caller outside of morn
+func callerLocation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !internalPackages[funcPackage(frame.Function)] && !strings.HasPrefix(frame.Function, "runtime.") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// funcPackage returns the import path of the package of a function name reported by runtime
+func funcPackage(name string) string {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return name
+	}
+	return name[:slash+1+dot]
+}
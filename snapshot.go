@@ -0,0 +1,66 @@
+package morn
+
+import (
+	"context"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Snapshot runs f with a context bound to a snapshot session
+// Every read made through that context (MFindMany, MCount, MAggregate, ...) across all collections
+// observes the data as of one cluster time. Writes fail with clause.ErrReadOnly.
+// The cluster time of the snapshot is returned so the same report can be reproduced later
+// by passing it back as option.SnapshotOption.AtClusterTime.
+// Warning:
+// - Snapshot reads require a replica set or sharded cluster running MongoDB 5.0+
+// - The server only keeps history for minSnapshotHistoryWindowInSeconds (5 minutes by default)
+func (i *Instance) Snapshot(ctx context.Context, f func(ctx context.Context) error, opt *option.SnapshotOption) (*bson.Timestamp, error) {
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+
+	sessionOpts := options.Session().SetSnapshot(true)
+	if opt != nil && opt.AtClusterTime != nil {
+		sessionOpts.SetSnapshotTime(*opt.AtClusterTime)
+	}
+	session, err := i.client.StartSession(sessionOpts)
+	if err != nil {
+		i.logger.Error("Failed to start snapshot session", err)
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+	ctxTracked, release, err := i.trackSession(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+	defer release(false)
+
+	ctxSnapshot := context.WithValue(mongo.NewSessionContext(ctxTracked, session), snapshotKey{}, session)
+	err = f(clause.ReadOnly(ctxSnapshot))
+
+	atClusterTime := session.SnapshotTime()
+	if atClusterTime.IsZero() {
+		return nil, err
+	}
+	return &atClusterTime, err
+}
+
+type snapshotKey struct{}
+
+// SnapshotTime returns the cluster time of the snapshot session bound to ctx
+// It is nil outside Instance.Snapshot or before the first read of the snapshot
+func SnapshotTime(ctx context.Context) *bson.Timestamp {
+	session, _ := ctx.Value(snapshotKey{}).(*mongo.Session)
+	if session == nil || session != mongo.SessionFromContext(ctx) {
+		return nil
+	}
+	atClusterTime := session.SnapshotTime()
+	if atClusterTime.IsZero() {
+		return nil
+	}
+	return &atClusterTime
+}
@@ -0,0 +1,146 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrTenantRequired = errors.New("tenant not found in context")
+
+// TenantStrategy decides where the documents of a tenant live
+// Resolve returns the collection holding colName for tenant and, for shared collections,
+// the scope every document of the tenant must match (see clause.Scope).
+type TenantStrategy interface {
+	Resolve(db *mongo.Database, colName string, tenant string) (*mongo.Collection, bson.M, error)
+}
+
+// TenantResolver extracts the tenant from the context of an operation
+type TenantResolver func(ctx context.Context) (string, bool)
+
+type tenantKey struct{}
+
+// WithTenant returns a context carrying tenant, read by the default TenantResolver
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext is the default TenantResolver, it reads the tenant set by WithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// DatabasePerTenant stores every tenant in its own database
+// name maps a tenant to its database name, when nil the tenant is used as is
+func DatabasePerTenant(name func(tenant string) string) TenantStrategy {
+	return databasePerTenant{name: name}
+}
+
+type databasePerTenant struct {
+	name func(tenant string) string
+}
+
+func (s databasePerTenant) Resolve(db *mongo.Database, colName string, tenant string) (*mongo.Collection, bson.M, error) {
+	dbName := tenant
+	if s.name != nil {
+		dbName = s.name(tenant)
+	}
+	if dbName == "" || strings.ContainsAny(dbName, "/\\. \"$") {
+		return nil, nil, fmt.Errorf("invalid database name %q for tenant %q", dbName, tenant)
+	}
+	return db.Client().Database(dbName).Collection(colName), nil, nil
+}
+
+// CollectionPrefix stores every tenant in its own collections named tenant + separator + collection
+func CollectionPrefix(separator string) TenantStrategy {
+	return collectionPrefix{separator: separator}
+}
+
+type collectionPrefix struct {
+	separator string
+}
+
+func (s collectionPrefix) Resolve(db *mongo.Database, colName string, tenant string) (*mongo.Collection, bson.M, error) {
+	if strings.ContainsAny(tenant, "$\x00") {
+		return nil, nil, fmt.Errorf("invalid collection prefix for tenant %q", tenant)
+	}
+	return db.Collection(tenant + s.separator + colName), nil, nil
+}
+
+// TenantField stores all tenants in the same collections, isolated by field
+// The field is added to every filter, insert and aggregate pipeline of the Dao
+// Warning:
+// - Unique indexes should include field, otherwise they span tenants
+func TenantField(field string) TenantStrategy {
+	return tenantField{field: field}
+}
+
+type tenantField struct {
+	field string
+}
+
+func (s tenantField) Resolve(db *mongo.Database, colName string, tenant string) (*mongo.Collection, bson.M, error) {
+	return db.Collection(colName), bson.M{s.field: tenant}, nil
+}
+
+// SetTenancy isolates the documents of every Dao by tenant
+// When resolver is nil TenantFromContext is used.
+// Daos with MornOption.SharedCollection set and the generator collection are not isolated.
+func (i *Instance) SetTenancy(strategy TenantStrategy, resolver TenantResolver) *Instance {
+	if resolver == nil {
+		resolver = TenantFromContext
+	}
+	i.tenancy = strategy
+	i.tenantResolver = resolver
+	return i
+}
+
+// WithTenancy configures the tenant isolation of the Instance, see Instance.SetTenancy
+func WithTenancy(strategy TenantStrategy, resolver TenantResolver) Option {
+	return func(c *setupConfig) error {
+		if strategy == nil {
+			return errors.New("morn: WithTenancy: strategy is nil")
+		}
+		c.tenancy = strategy
+		c.tenantResolver = resolver
+		return nil
+	}
+}
+
+// resolve returns the collection and scope of the Dao for the tenant of ctx
+func (d *Dao) resolve(ctx context.Context) (*mongo.Collection, bson.M, error) {
+	return d.resolveIn(ctx, nil)
+}
+
+// resolveIn is resolve on the database db, nil being the database of the Dao
+func (d *Dao) resolveIn(ctx context.Context, db *mongo.Database) (*mongo.Collection, bson.M, error) {
+	if d.ins == nil || d.ins.tenancy == nil || d.option.SharedCollection {
+		if db == nil || (d.collection != nil && db == d.collection.Database()) {
+			return d.collection, nil, nil
+		}
+		return d.collectionIn(db), nil, nil
+	}
+	if db == nil {
+		db = d.ins.GetDB()
+	}
+	tenant, ok := d.ins.tenantResolver(ctx)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: %w", d.colName, ErrTenantRequired)
+	}
+	collection, scope, err := d.ins.tenancy.Resolve(db, d.colName, tenant)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts := d.option.ToCollectionOptions(); opts != nil {
+		collection = collection.Clone(opts)
+	}
+	return collection, scope, nil
+}
@@ -9,7 +9,7 @@ import (
 func TestMAggregate(t *testing.T) {
 	ins := setupTestDB(t)
 
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 	defer cleanupTestDB(t, userDao, ins)
 
 	// Create test data
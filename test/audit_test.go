@@ -0,0 +1,210 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const auditCollection = "audit_test"
+
+func auditRecords(t *testing.T, ins *morn.Instance, documentID interface{}) []morn.AuditRecord {
+	t.Helper()
+	cursor, err := ins.GetDB().Collection(auditCollection).Find(context.Background(), bson.M{"document_id": documentID},
+		options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	var records []morn.AuditRecord
+	if err := cursor.All(context.Background(), &records); err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	return records
+}
+
+func changedFields(record morn.AuditRecord) map[string]morn.AuditChange {
+	fields := make(map[string]morn.AuditChange, len(record.Changes))
+	for _, change := range record.Changes {
+		fields[change.Field] = change
+	}
+	return fields
+}
+
+func TestAuditTrail(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+	defer ins.GetDB().Collection(auditCollection).Drop(context.Background())
+
+	opt := ins.GetOptsField()
+	opt.Audit = &option.AuditOption{Collection: auditCollection}
+	auditedDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+	ctx := morn.WithActor(context.Background(), "alice")
+
+	id, err := auditedDao.Ctx(ctx).MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: 1})
+	if err != nil {
+		t.Fatalf("MCreateOne() error = %v", err)
+	}
+	if err := auditedDao.Ctx(ctx).Where(bson.M{"_id": id}).MUpdateOne(bson.M{"email": "new@example.com"}); err != nil {
+		t.Fatalf("MUpdateOne() error = %v", err)
+	}
+	if _, err := auditedDao.Ctx(ctx).Where(bson.M{"_id": id}).MReplaceOne(bson.M{"username": "user2", "user_id": 1}); err != nil {
+		t.Fatalf("MReplaceOne() error = %v", err)
+	}
+	if err := auditedDao.Ctx(ctx).Where(bson.M{"_id": id}).MDelete(); err != nil {
+		t.Fatalf("MDelete() error = %v", err)
+	}
+
+	records := auditRecords(t, ins, id)
+	if len(records) != 4 {
+		t.Fatalf("Expected 4 audit records, got %d", len(records))
+	}
+	wantOps := []string{"insertOne", "updateOne", "replaceOne", "deleteOne"}
+	for i, record := range records {
+		if record.Operation != wantOps[i] {
+			t.Errorf("Record %d: expected operation %s, got %s", i, wantOps[i], record.Operation)
+		}
+		if record.Actor != "alice" || record.Collection != UserCollection || record.Timestamp.IsZero() {
+			t.Errorf("Record %d: unexpected actor, collection or timestamp: %+v", i, record)
+		}
+	}
+
+	if change, ok := changedFields(records[0])["username"]; !ok || change.Before != nil || change.After != "user1" {
+		t.Errorf("Expected the created username, got %+v", records[0].Changes)
+	}
+	update := changedFields(records[1])
+	if change := update["email"]; change.Before != "user1@example.com" || change.After != "new@example.com" {
+		t.Errorf("Expected the email change, got %+v", records[1].Changes)
+	}
+	if _, ok := update["username"]; ok {
+		t.Errorf("Expected unchanged fields to be left out, got %+v", records[1].Changes)
+	}
+	if records[1].Filter == nil {
+		t.Error("Expected the filter of the update to be recorded")
+	}
+	replace := changedFields(records[2])
+	if change, ok := replace["email"]; !ok || change.After != nil {
+		t.Errorf("Expected the replace to remove the email, got %+v", records[2].Changes)
+	}
+	if change, ok := changedFields(records[3])["username"]; !ok || change.Before != "user2" || change.After != nil {
+		t.Errorf("Expected the deleted username, got %+v", records[3].Changes)
+	}
+
+	// a write without matching document leaves no record
+	if err := auditedDao.Ctx(ctx).Where(bson.M{"user_id": -1}).MUpdateOne(bson.M{"email": "none@example.com"}); err != nil {
+		t.Fatalf("MUpdateOne() error = %v", err)
+	}
+	if count, _ := ins.GetDB().Collection(auditCollection).CountDocuments(context.Background(), bson.M{}); count != 4 {
+		t.Errorf("Expected 4 audit records in total, got %d", count)
+	}
+}
+
+func TestAuditTrailSession(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+	defer ins.GetDB().Collection(auditCollection).Drop(context.Background())
+
+	opt := ins.GetOptsField()
+	opt.Audit = &option.AuditOption{
+		Collection: auditCollection,
+		Actor: func(ctx context.Context) (string, bool) {
+			return "system", true
+		},
+	}
+	auditedDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+
+	var id interface{}
+	err := auditedDao.Session(context.Background(), func(ctx context.Context) error {
+		var err error
+		id, err = auditedDao.Ctx(ctx).MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: 1})
+		if err != nil {
+			return err
+		}
+		return errors.New("abort")
+	}, nil)
+	if err == nil {
+		t.Fatal("Expected the session to fail")
+	}
+	if records := auditRecords(t, ins, id); len(records) != 0 {
+		t.Errorf("Expected the audit record to be rolled back, got %d", len(records))
+	}
+
+	err = auditedDao.Session(context.Background(), func(ctx context.Context) error {
+		var err error
+		id, err = auditedDao.Ctx(ctx).MCreateOne(&User{Username: "user2", Email: "user2@example.com", UserID: 2})
+		return err
+	}, nil)
+	if err != nil {
+		t.Fatalf("Session() error = %v", err)
+	}
+	records := auditRecords(t, ins, id)
+	if len(records) != 1 || records[0].Actor != "system" {
+		t.Errorf("Expected one audit record by system, got %+v", records)
+	}
+}
+
+func TestAuditTrailMaxDocuments(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+	defer ins.GetDB().Collection(auditCollection).Drop(context.Background())
+
+	opt := ins.GetOptsField()
+	opt.Audit = &option.AuditOption{Collection: auditCollection, MaxDocuments: 1}
+	auditedDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+	ctx := context.Background()
+
+	for i := 1; i <= 2; i++ {
+		if _, err := userDao.Ctx(ctx).MCreateOne(&User{Username: "user", UserID: int64(i)}); err != nil {
+			t.Fatalf("MCreateOne() error = %v", err)
+		}
+	}
+	err := auditedDao.Ctx(ctx).Where(bson.M{"username": "user"}).MUpdateMany(bson.M{"email": "new@example.com"})
+	if !errors.Is(err, morn.ErrAuditFailed) {
+		t.Fatalf("Expected ErrAuditFailed, got %v", err)
+	}
+	if count, _ := userDao.Ctx(ctx).Where(bson.M{"email": "new@example.com"}).MCount(); count != 0 {
+		t.Errorf("Expected no document updated, got %d", count)
+	}
+}
+
+func TestAuditTrailUnchanged(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+	defer ins.GetDB().Collection(auditCollection).Drop(context.Background())
+
+	opt := ins.GetOptsField()
+	opt.Audit = &option.AuditOption{Collection: auditCollection}
+	auditedDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+	ctx := context.Background()
+
+	inserted, err := userDao.Col().InsertOne(ctx, bson.M{"username": "user1", "user_id": 1})
+	if err != nil {
+		t.Fatalf("InsertOne() error = %v", err)
+	}
+	res, err := auditedDao.Ctx(ctx).Where(bson.M{"_id": inserted.InsertedID}).MReplaceOne(bson.M{"username": "user1", "user_id": 1})
+	if err != nil {
+		t.Fatalf("MReplaceOne() error = %v", err)
+	}
+	if res.MatchedCount != 1 || res.ModifiedCount != 0 {
+		t.Errorf("Expected 1 matched and 0 modified documents, got %+v", res)
+	}
+	if records := auditRecords(t, ins, inserted.InsertedID); len(records) != 0 {
+		t.Errorf("Expected no audit record, got %+v", records)
+	}
+
+	res, err = auditedDao.Ctx(ctx).Where(bson.M{"_id": inserted.InsertedID}).MReplaceOne(bson.M{"username": "user2", "user_id": 1})
+	if err != nil {
+		t.Fatalf("MReplaceOne() error = %v", err)
+	}
+	if res.MatchedCount != 1 || res.ModifiedCount != 1 {
+		t.Errorf("Expected 1 matched and 1 modified documents, got %+v", res)
+	}
+}
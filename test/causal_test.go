@@ -0,0 +1,57 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestCausalSession(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Errorf("Failed to generate user ID: %v", err)
+	}
+
+	err = ins.Causal(context.Background(), func(ctx context.Context) error {
+		if mongo.SessionFromContext(ctx) == nil {
+			t.Errorf("Causal() context is not bound to a session")
+		}
+
+		_, err := userDao.Ctx(ctx).MCreateOne(&User{Username: "causal", Email: "causal@example.com", UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		result := &User{}
+		err = userDao.Ctx(ctx).Where(map[string]interface{}{"user_id": userID}).MFindOne(result)
+		if err != nil {
+			return err
+		}
+		if result.Username != "causal" {
+			t.Errorf("Read after write returned %+v", result)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Causal() error = %v", err)
+	}
+
+	handler := ins.CausalMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mongo.SessionFromContext(r.Context()) == nil {
+			t.Errorf("CausalMiddleware() request context is not bound to a session")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("CausalMiddleware() status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func TestConnectionRouting(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	analytics := ins.GetClient().Database("Cluster0_analytics")
+	defer analytics.Drop(context.Background())
+	if err := ins.AddConnection("analytics", analytics); err != nil {
+		t.Fatalf("AddConnection() error = %v", err)
+	}
+	if err := ins.AddConnection("analytics", analytics); err == nil {
+		t.Error("Expected an error when registering a connection twice")
+	}
+
+	opt := ins.GetOptsField()
+	opt.ReadConnection = "analytics"
+	opt.AggregateConnection = morn.DefaultConnection
+	reportDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Fatalf("Failed to generate user ID: %v", err)
+	}
+	if _, err := reportDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: userID}); err != nil {
+		t.Fatalf("MCreateOne() error = %v", err)
+	}
+
+	count, err := reportDao.Clause().MCount()
+	if err != nil {
+		t.Fatalf("MCount() error = %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected reads from the analytics connection to see 0 users, got %d", count)
+	}
+
+	cursor, err := reportDao.Clause().Aggregate([]bson.M{{"$match": bson.M{"user_id": userID}}})
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	var result []bson.M
+	if err := cursor.All(context.Background(), &result); err != nil {
+		t.Fatalf("Failed to decode aggregate: %v", err)
+	}
+	if len(result) != 1 {
+		t.Errorf("Expected aggregates on the default connection to see 1 user, got %d", len(result))
+	}
+}
+
+func TestConnectionWriteOnly(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	primary := ins.GetClient().Database("Cluster0_primary")
+	defer primary.Drop(context.Background())
+	if err := ins.AddConnection("primary", primary); err != nil {
+		t.Fatalf("AddConnection() error = %v", err)
+	}
+
+	// only writes are bound, reads and aggregates stay on the default connection
+	opt := ins.GetOptsField()
+	opt.WriteConnection = "primary"
+	writeDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+
+	if _, err := writeDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com"}); err != nil {
+		t.Fatalf("MCreateOne() error = %v", err)
+	}
+	if count, err := primary.Collection(UserCollection).CountDocuments(context.Background(), bson.M{}); err != nil || count != 1 {
+		t.Errorf("Expected the write on the primary connection, got %d (%v)", count, err)
+	}
+
+	count, err := writeDao.Clause().MCount()
+	if err != nil {
+		t.Fatalf("MCount() error = %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected reads from the default connection to see 0 users, got %d", count)
+	}
+	var result []bson.M
+	if err := writeDao.Clause().MAggregate(&result, []bson.M{}); err != nil {
+		t.Fatalf("MAggregate() error = %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("Expected aggregates on the default connection to see 0 users, got %d", len(result))
+	}
+}
+
+func TestConnectionFallback(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	unreachable, err := mongo.Connect(options.Client().
+		SetHosts([]string{"localhost:1"}).
+		SetServerSelectionTimeout(200 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer unreachable.Disconnect(context.Background())
+
+	if err := ins.AddConnection("replica", unreachable.Database("Cluster0"), morn.DefaultConnection); err != nil {
+		t.Fatalf("AddConnection() error = %v", err)
+	}
+
+	opt := ins.GetOptsField()
+	opt.ReadConnection = "replica"
+	readDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+	if _, err := readDao.Clause().MCount(); err != nil {
+		t.Errorf("Expected the read to fall back to the default connection, got %v", err)
+	}
+
+	opt.ReadConnection = ""
+	opt.WriteConnection = "replica"
+	writeDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+	if _, err := writeDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com"}); err == nil {
+		t.Error("Expected the write on an unreachable connection to fail without falling back")
+	}
+
+	opt.WriteConnection = "missing"
+	missingDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+	if _, err := missingDao.Clause().MCount(); !errors.Is(err, morn.ErrUnknownConnection) {
+		t.Errorf("Expected ErrUnknownConnection, got %v", err)
+	}
+}
+
+func TestWithConnectionValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  morn.Option
+	}{
+		{name: "Reserved name", opt: morn.WithConnection(morn.DefaultConnection, options.Client(), "Cluster0")},
+		{name: "Nil client options", opt: morn.WithConnection("analytics", nil, "Cluster0")},
+		{name: "Empty database", opt: morn.WithConnection("analytics", options.Client(), "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := morn.New(context.Background(), morn.WithURI("mongodb://localhost:27017"), tt.opt); err == nil {
+				t.Error("Expected New to fail")
+			}
+		})
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+	_, err = morn.FromClient(client, morn.WithConnection("analytics", options.Client(), "Cluster0"), morn.WithMornOption(option.MornOption{}))
+	if err == nil {
+		t.Error("Expected FromClient to reject WithConnection")
+	}
+}
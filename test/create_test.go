@@ -7,7 +7,7 @@ import (
 func TestCreate(t *testing.T) {
 	ins := setupTestDB(t)
 
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 
 	defer cleanupTestDB(t, userDao, ins)
 	userID, err := userDao.GenIDForDao()
@@ -52,7 +52,7 @@ func TestCreate(t *testing.T) {
 func TestCreateMany(t *testing.T) {
 	ins := setupTestDB(t)
 
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 	defer cleanupTestDB(t, userDao, ins)
 	userID1, err := userDao.GenIDForDao()
 	if err != nil {
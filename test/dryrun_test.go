@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/logger"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// dryRunClause returns a DryRun clause on a collection of a client that never connects
+func dryRunClause(t *testing.T) *clause.Clause {
+	t.Helper()
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	collection := client.Database("morn").Collection(UserCollection)
+	return clause.NewClause(collection, logger.NewFmtLogger(), User{}, option.MornOption{
+		CreateAtField: "created_at",
+		UpdateAtField: "updated_at",
+	}, context.Background()).DryRun()
+}
+
+func TestDryRunFind(t *testing.T) {
+	c := dryRunClause(t)
+	var users []User
+	err := c.Where(bson.M{"age": bson.M{"$gt": 18}}).Sort("age:desc").Limit(10).MFindMany(&users)
+	if !errors.Is(err, clause.ErrDryRun) {
+		t.Fatalf("MFindMany() error = %v, want ErrDryRun", err)
+	}
+	var statement *clause.Statement
+	if !errors.As(err, &statement) || statement != c.Statement() {
+		t.Fatal("Expected the error to be the Statement of the clause")
+	}
+
+	shell, err := statement.Mongosh()
+	if err != nil {
+		t.Fatalf("Mongosh() error = %v", err)
+	}
+	want := `db.getCollection("users").find({"age": {"$gt": 18}}, {}, {"sort": {"age": -1}, "limit": NumberLong("10")})`
+	if shell != want {
+		t.Errorf("Mongosh() = %s, want %s", shell, want)
+	}
+	if statement.Database != "morn" || statement.Collection != UserCollection || statement.Operation != "find" {
+		t.Errorf("Unexpected statement %+v", statement)
+	}
+}
+
+func TestDryRunWrites(t *testing.T) {
+	c := dryRunClause(t)
+	err := c.Scope(bson.M{"tenant": "acme"}).Where(bson.M{"user_id": 1}).MUpdateOne(map[string]interface{}{"email": "new@example.com"})
+	if !errors.Is(err, clause.ErrDryRun) {
+		t.Fatalf("MUpdateOne() error = %v, want ErrDryRun", err)
+	}
+	statement := c.Statement()
+	filter, ok := statement.Filter.(bson.M)
+	if !ok || filter["tenant"] != "acme" || filter["user_id"] != 1 {
+		t.Errorf("Expected the scoped filter, got %v", statement.Filter)
+	}
+	set, _ := statement.Update.(bson.M)["$set"].(bson.M)
+	if set["email"] != "new@example.com" || set["updated_at"] == nil {
+		t.Errorf("Expected the update with updated_at, got %v", statement.Update)
+	}
+	if len(statement.Command) == 0 || statement.Command[0].Key != "update" {
+		t.Errorf("Expected the update command, got %v", statement.Command)
+	}
+
+	c = dryRunClause(t)
+	if _, err := c.MCreateOne(&User{Username: "user1", UserID: 1}); !errors.Is(err, clause.ErrDryRun) {
+		t.Fatalf("MCreateOne() error = %v, want ErrDryRun", err)
+	}
+	shell, err := c.Statement().Mongosh()
+	if err != nil {
+		t.Fatalf("Mongosh() error = %v", err)
+	}
+	if !strings.HasPrefix(shell, `db.getCollection("users").insertOne({`) || !strings.Contains(shell, `"created_at": ISODate(`) {
+		t.Errorf("Unexpected insert %s", shell)
+	}
+
+	c = dryRunClause(t)
+	if err := c.Where(bson.M{"user_id": 1}).MDelete(); !errors.Is(err, clause.ErrDryRun) {
+		t.Fatalf("MDelete() error = %v, want ErrDryRun", err)
+	}
+	extJSON, err := c.Statement().ExtJSON()
+	if err != nil {
+		t.Fatalf("ExtJSON() error = %v", err)
+	}
+	if want := `{"database":"morn","collection":"users","operation":"deleteOne","filter":{"user_id":1}}`; extJSON != want {
+		t.Errorf("ExtJSON() = %s, want %s", extJSON, want)
+	}
+
+	c = dryRunClause(t)
+	if err := c.CreateIndex("user_id:1"); !errors.Is(err, clause.ErrDryRun) {
+		t.Fatalf("CreateIndex() error = %v, want ErrDryRun", err)
+	}
+	shell, err = c.Statement().Mongosh()
+	if err != nil {
+		t.Fatalf("Mongosh() error = %v", err)
+	}
+	if want := `db.getCollection("users").createIndex({"user_id": 1}, {"name": "user_id_1"})`; shell != want {
+		t.Errorf("Mongosh() = %s, want %s", shell, want)
+	}
+}
+
+func TestDryRunScopedAggregate(t *testing.T) {
+	scope := bson.M{"tenant": "acme"}
+	lookup := bson.M{"$lookup": bson.M{"from": "orders", "localField": "user_id", "foreignField": "user_id", "as": "orders"}}
+	tests := []struct {
+		name     string
+		pipeline []bson.M
+		want     []bson.M
+		wantErr  error
+	}{
+		{
+			name:     "Scope is matched first",
+			pipeline: []bson.M{{"$match": bson.M{"age": 18}}},
+			want:     []bson.M{{"$match": scope}, {"$match": bson.M{"age": 18}}},
+		},
+		{
+			name:     "Joined collections are scoped",
+			pipeline: []bson.M{lookup, {"$unionWith": "admins"}},
+			want: []bson.M{
+				{"$match": scope},
+				{"$lookup": bson.M{"from": "orders", "localField": "user_id", "foreignField": "user_id", "as": "orders", "pipeline": []bson.M{{"$match": scope}}}},
+				{"$unionWith": bson.M{"coll": "admins", "pipeline": []bson.M{{"$match": scope}}}},
+			},
+		},
+		{
+			name:     "Facets scope their joins only",
+			pipeline: []bson.M{{"$facet": bson.M{"orders": bson.A{lookup}}}},
+			want: []bson.M{
+				{"$match": scope},
+				{"$facet": bson.M{"orders": []bson.M{{"$lookup": bson.M{"from": "orders", "localField": "user_id", "foreignField": "user_id", "as": "orders", "pipeline": []bson.M{{"$match": scope}}}}}}},
+			},
+		},
+		{
+			name:     "Graph lookups restrict their search",
+			pipeline: []bson.M{{"$graphLookup": bson.M{"from": "users", "startWith": "$manager", "connectFromField": "manager", "connectToField": "user_id", "as": "managers"}}},
+			want: []bson.M{
+				{"$match": scope},
+				{"$graphLookup": bson.M{"from": "users", "startWith": "$manager", "connectFromField": "manager", "connectToField": "user_id", "as": "managers", "restrictSearchWithMatch": scope}},
+			},
+		},
+		{
+			name:     "Leading $geoNear keeps its position",
+			pipeline: []bson.M{{"$geoNear": bson.M{"near": bson.A{0, 0}, "distanceField": "distance"}}},
+			want:     []bson.M{{"$geoNear": bson.M{"near": bson.A{0, 0}, "distanceField": "distance", "query": scope}}},
+		},
+		{
+			name:     "Scope is matched after $search",
+			pipeline: []bson.M{{"$search": bson.M{"text": bson.M{"query": "user", "path": "username"}}}},
+			want:     []bson.M{{"$search": bson.M{"text": bson.M{"query": "user", "path": "username"}}}, {"$match": scope}},
+		},
+		{
+			name:     "Stages reading other data are rejected",
+			pipeline: []bson.M{{"$collStats": bson.M{"count": bson.M{}}}},
+			wantErr:  clause.ErrUnscopedStage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dryRunClause(t).Scope(scope)
+			err := c.MAggregate(&[]bson.M{}, tt.pipeline)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("MAggregate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if !errors.Is(err, clause.ErrDryRun) {
+				t.Fatalf("MAggregate() error = %v, want ErrDryRun", err)
+			}
+			if got := c.Statement().Pipeline; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pipeline = %v, want %v", got, tt.want)
+			}
+			if _, ok := lookup["$lookup"].(bson.M)["pipeline"]; ok {
+				t.Errorf("The pipeline of the caller was modified: %v", lookup)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestExplain(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	users := []User{
+		{Username: "user1", Email: "user1@example.com", UserID: 1},
+		{Username: "user2", Email: "user2@example.com", UserID: 2},
+	}
+	if _, err := userDao.Clause().MCreateMany(users); err != nil {
+		t.Fatalf("MCreateMany() error = %v", err)
+	}
+	ctx := context.Background()
+
+	res, err := userDao.Clause().Where(bson.M{"user_id": 1}).Explain(ctx, clause.ExecutionStats).Find()
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if !res.IndexBacked() || res.Indexes[0] != "user_id_1" {
+		t.Errorf("Expected the user_id index, got %s", res.Plan)
+	}
+	if res.Returned != 1 || res.KeysExamined != 1 {
+		t.Errorf("Expected 1 key examined and 1 document returned, got %d and %d", res.KeysExamined, res.Returned)
+	}
+
+	res, err = userDao.Clause().Where(bson.M{"password": "secret"}).Sort("point:desc").Explain(ctx, clause.QueryPlanner).Find()
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if !res.CollScan || !res.InMemorySort || res.IndexBacked() {
+		t.Errorf("Expected a collection scan sorted in memory, got %s", res.Plan)
+	}
+
+	res, err = userDao.Clause().Where(bson.M{"user_id": bson.M{"$gte": 1}}).Explain(ctx, clause.ExecutionStats).Count()
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if !res.IndexBacked() {
+		t.Errorf("Expected the count to use the user_id index, got %s", res.Plan)
+	}
+
+	res, err = userDao.Clause().Where(bson.M{"user_id": 2}).Explain(ctx, clause.ExecutionStats).DeleteOne()
+	if err != nil {
+		t.Fatalf("DeleteOne() error = %v", err)
+	}
+	if !res.IndexBacked() {
+		t.Errorf("Expected the delete to use the user_id index, got %s", res.Plan)
+	}
+	if count, _ := userDao.Clause().Where(bson.M{"user_id": 2}).MCount(); count != 1 {
+		t.Error("Expected explain to leave the document in place")
+	}
+
+	res, err = userDao.Clause().Explain(ctx, clause.QueryPlanner).Aggregate([]bson.M{
+		{"$match": bson.M{"user_id": bson.M{"$gt": 0}}},
+		{"$group": bson.M{"_id": "$username"}},
+	})
+	if err != nil {
+		t.Fatalf("Aggregate() error = %v", err)
+	}
+	if res.Plan == nil || !res.IndexBacked() {
+		t.Errorf("Expected the $match to use the user_id index, got %s", res.Plan)
+	}
+}
+
+func TestParseExplain(t *testing.T) {
+	classic := bson.M{
+		"queryPlanner": bson.M{
+			"winningPlan": bson.M{
+				"stage": "SORT",
+				"inputStage": bson.M{
+					"stage":      "FETCH",
+					"inputStage": bson.M{"stage": "IXSCAN", "indexName": "user_id_1"},
+				},
+			},
+		},
+		"executionStats": bson.M{
+			"nReturned":           int32(2),
+			"totalKeysExamined":   int32(2),
+			"totalDocsExamined":   int32(4),
+			"executionTimeMillis": int32(3),
+		},
+	}
+	res := clause.ParseExplain(classic)
+	if got := res.Plan.String(); got != "SORT > FETCH > IXSCAN user_id_1" {
+		t.Errorf("Plan = %q", got)
+	}
+	if !res.InMemorySort || res.CollScan || !res.IndexBacked() {
+		t.Errorf("Unexpected flags %+v", res)
+	}
+	if res.DocsExamined != 4 || res.Returned != 2 || res.DocsExaminedRatio() != 2 || res.ExecutionTime.Milliseconds() != 3 {
+		t.Errorf("Unexpected stats %+v", res)
+	}
+
+	// slot based engine plan of an aggregation
+	sbe := bson.M{
+		"stages": bson.A{
+			bson.M{"$cursor": bson.M{
+				"queryPlanner": bson.M{
+					"winningPlan": bson.M{"queryPlan": bson.M{"stage": "COLLSCAN"}},
+				},
+			}},
+			bson.M{"$sort": bson.M{"sortKey": bson.M{"age": 1}}},
+		},
+	}
+	res = clause.ParseExplain(sbe)
+	if res.Plan.String() != "COLLSCAN" || !res.CollScan || !res.InMemorySort || res.IndexBacked() {
+		t.Errorf("Unexpected aggregation result %+v", res)
+	}
+}
@@ -9,7 +9,7 @@ import (
 func TestFindOne(t *testing.T) {
 	ins := setupTestDB(t)
 
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 	defer cleanupTestDB(t, userDao, ins)
 
 	// Create test data
@@ -77,7 +77,7 @@ func TestFindOne(t *testing.T) {
 func TestFindMany(t *testing.T) {
 	ins := setupTestDB(t)
 
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 	defer cleanupTestDB(t, userDao, ins)
 
 	// Create test data
@@ -203,3 +203,36 @@ func TestFindMany(t *testing.T) {
 		})
 	}
 }
+
+func TestFindOneSorted(t *testing.T) {
+	ins := setupTestDB(t)
+
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	for i, point := range []int64{20, 30, 10} {
+		user := &User{Username: "sorted", UserID: int64(i + 1), Point: point}
+		if _, err := userDao.Clause().MCreateOne(user); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+	}
+
+	tests := []struct {
+		sort string
+		want int64
+	}{
+		{sort: "point:desc", want: 30},
+		{sort: "point:asc", want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			result := &User{}
+			if err := userDao.Clause().Where(bson.M{"username": "sorted"}).Sort(tt.sort).MFindOne(result); err != nil {
+				t.Fatalf("MFindOne() error = %v", err)
+			}
+			if result.Point != tt.want {
+				t.Errorf("MFindOne() point = %d, want %d", result.Point, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nghialthanh/morn-go"
+)
+
+func TestHealth(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	if err := ins.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+
+	report, err := ins.Health(context.Background())
+	if err != nil {
+		t.Fatalf("Health() error = %v", err)
+	}
+	if !report.PrimaryReachable || report.Status == morn.HealthDown {
+		t.Errorf("Health() status = %s, primary reachable = %v", report.Status, report.PrimaryReachable)
+	}
+	if len(report.Members) == 0 {
+		t.Errorf("Health() reported no members")
+	}
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		want    int
+	}{
+		{
+			name:    "Liveness",
+			handler: ins.LivenessHandler(),
+			want:    http.StatusOK,
+		},
+		{
+			name:    "Readiness",
+			handler: ins.ReadinessHandler(),
+			want:    http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			body := map[string]interface{}{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Errorf("body is not JSON: %v", err)
+			}
+			if body["status"] != morn.HealthOK && body["status"] != morn.HealthDegraded {
+				t.Errorf("body status = %v", body["status"])
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nghialthanh/morn-go"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var errUsernameRequired = errors.New("username is required")
+
+type HookedUser struct {
+	ID       *bson.ObjectID `bson:"_id,omitempty"`
+	Username string         `bson:"username"`
+	Email    string         `bson:"email"`
+
+	Events    []string `bson:"-"`
+	InSession bool     `bson:"-"`
+}
+
+func (u *HookedUser) BeforeCreate(ctx context.Context) error {
+	if u.Username == "" {
+		return errUsernameRequired
+	}
+	u.Events = append(u.Events, "BeforeCreate")
+	return nil
+}
+
+func (u *HookedUser) AfterCreate(ctx context.Context) error {
+	u.Events = append(u.Events, "AfterCreate")
+	return nil
+}
+
+func (u *HookedUser) BeforeUpdate(ctx context.Context) error {
+	u.Events = append(u.Events, "BeforeUpdate")
+	u.InSession = mongo.SessionFromContext(ctx) != nil
+	return nil
+}
+
+func (u *HookedUser) AfterUpdate(ctx context.Context) error {
+	u.Events = append(u.Events, "AfterUpdate")
+	return nil
+}
+
+func (u *HookedUser) AfterFind(ctx context.Context) error {
+	u.Events = append(u.Events, "AfterFind")
+	return nil
+}
+
+func (u *HookedUser) BeforeDelete(ctx context.Context) error {
+	u.Events = append(u.Events, "BeforeDelete")
+	return nil
+}
+
+func (u *HookedUser) AfterDelete(ctx context.Context) error {
+	u.Events = append(u.Events, "AfterDelete")
+	return nil
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	hookDao := morn.NewDao(UserCollection, HookedUser{}, ins, nil)
+
+	t.Run("Before hook aborts the operation", func(t *testing.T) {
+		_, err := hookDao.Clause().MCreateOne(&HookedUser{Email: "nobody@example.com"})
+		if !errors.Is(err, errUsernameRequired) {
+			t.Fatalf("Expected errUsernameRequired, got %v", err)
+		}
+		count, err := hookDao.Clause().Where(bson.M{"email": "nobody@example.com"}).MCount()
+		if err != nil || count != 0 {
+			t.Errorf("Expected no document to be inserted, got %d (%v)", count, err)
+		}
+	})
+
+	user := &HookedUser{Username: "hooked", Email: "hooked@example.com"}
+	t.Run("Create", func(t *testing.T) {
+		if _, err := hookDao.Clause().MCreateOne(user); err != nil {
+			t.Fatalf("MCreateOne() error = %v", err)
+		}
+		assertEvents(t, user.Events, "BeforeCreate", "AfterCreate")
+	})
+
+	t.Run("Find many runs per element", func(t *testing.T) {
+		others := []HookedUser{{Username: "a", Email: "a@example.com"}, {Username: "b", Email: "b@example.com"}}
+		if _, err := hookDao.Clause().MCreateMany(&others); err != nil {
+			t.Fatalf("MCreateMany() error = %v", err)
+		}
+		var found []HookedUser
+		if err := hookDao.Clause().MFindMany(&found); err != nil {
+			t.Fatalf("MFindMany() error = %v", err)
+		}
+		if len(found) != 3 {
+			t.Fatalf("Expected 3 users, got %d", len(found))
+		}
+		for _, u := range found {
+			assertEvents(t, u.Events, "AfterFind")
+		}
+	})
+
+	t.Run("Update in session", func(t *testing.T) {
+		updater := &HookedUser{Username: "hooked", Email: "updated@example.com"}
+		err := hookDao.Session(context.Background(), func(ctx context.Context) error {
+			return hookDao.Ctx(ctx).Where(bson.M{"username": "hooked"}).MUpdateOne(updater)
+		}, nil)
+		if err != nil {
+			t.Fatalf("Session() error = %v", err)
+		}
+		assertEvents(t, updater.Events, "BeforeUpdate", "AfterUpdate")
+		if !updater.InSession {
+			t.Error("Expected the hook to receive the session context")
+		}
+	})
+
+	t.Run("Find one and delete", func(t *testing.T) {
+		found := &HookedUser{}
+		if err := hookDao.Clause().Where(bson.M{"username": "hooked"}).MFindOne(found); err != nil {
+			t.Fatalf("MFindOne() error = %v", err)
+		}
+		if err := hookDao.Clause().Model(found).Where(bson.M{"_id": found.ID}).MDelete(); err != nil {
+			t.Fatalf("MDelete() error = %v", err)
+		}
+		assertEvents(t, found.Events, "AfterFind", "BeforeDelete", "AfterDelete")
+	})
+}
+
+func assertEvents(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("Hooks = %v, want %v", got, want)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Hooks = %v, want %v", got, want)
+			return
+		}
+	}
+}
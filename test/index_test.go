@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const indexCollection = "index_test"
+
+type Session struct {
+	Token     string    `bson:"token" morn:"index:uniq_token,unique"`
+	TenantID  string    `bson:"tenant_id" morn:"index:tenant_seen"`
+	LastSeen  time.Time `bson:"last_seen" morn:"index:tenant_seen,desc"`
+	ExpiresAt time.Time `bson:"expires_at" morn:"index,ttl=1h"`
+	Device    Device    `bson:"device"`
+	Deleted   bool      `bson:"deleted"`
+}
+
+type Device struct {
+	Serial string `bson:"serial" morn:"index:uniq_serial,unique,partial={\"deleted\": false, \"device.serial\": {\"$exists\": true}}"`
+}
+
+func TestIndexTags(t *testing.T) {
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Disconnect(context.Background())
+	ins, err := morn.FromClient(client)
+	if err != nil {
+		t.Fatalf("FromClient() error = %v", err)
+	}
+
+	indexes, err := morn.NewDao(indexCollection, Session{}, ins, nil).Indexes()
+	if err != nil {
+		t.Fatalf("Indexes() error = %v", err)
+	}
+	if len(indexes) != 4 {
+		t.Fatalf("Expected 4 indexes, got %+v", indexes)
+	}
+	if strings.Join(indexes[1].Keys, " ") != "tenant_id:1 last_seen:-1" || *indexes[1].Option.Name != "tenant_seen" {
+		t.Errorf("Expected the compound index in field order, got %+v", indexes[1])
+	}
+	if indexes[2].Option.ExpireAfterSeconds == nil || *indexes[2].Option.ExpireAfterSeconds != 3600 || indexes[2].Option.Name != nil {
+		t.Errorf("Expected the unnamed TTL index, got %+v", indexes[2])
+	}
+	if indexes[3].Keys[0] != "device.serial:1" || indexes[3].Option.PartialFilterExpression == nil || !*indexes[3].Option.Unique {
+		t.Errorf("Expected the nested partial index, got %+v", indexes[3])
+	}
+
+	type invalid struct {
+		Name string `morn:"index,unknown"`
+	}
+	if _, err := morn.NewDao(indexCollection, invalid{}, ins, nil).Indexes(); err == nil {
+		t.Error("Expected an error for an unknown index option")
+	}
+	type overflow struct {
+		ExpiresAt time.Time `morn:"index,ttl=1000000h"`
+	}
+	if _, err := morn.NewDao(indexCollection, overflow{}, ins, nil).Indexes(); err == nil {
+		t.Error("Expected an error for a ttl overflowing int32 seconds")
+	}
+}
+
+func TestSyncIndexes(t *testing.T) {
+	ins := setupTestDB(t)
+	ctx := context.Background()
+	collection := ins.GetDB().Collection(indexCollection)
+	collection.Drop(ctx)
+	defer collection.Drop(ctx)
+	dao := morn.NewDao(indexCollection, Session{}, ins, nil)
+
+	plan, err := dao.SyncIndexes(ctx, morn.SyncPlan)
+	if err != nil {
+		t.Fatalf("SyncIndexes(SyncPlan) error = %v", err)
+	}
+	if len(plan.Missing) != 4 || plan.InSync() {
+		t.Fatalf("Expected 4 missing indexes, got\n%s", plan)
+	}
+	if names, _ := collection.Indexes().ListSpecifications(ctx); len(names) != 0 {
+		t.Errorf("Expected the plan mode to leave the collection unchanged, got %d indexes", len(names))
+	}
+
+	if _, err := dao.SyncIndexes(ctx, morn.SyncCreate); err != nil {
+		t.Fatalf("SyncIndexes(SyncCreate) error = %v", err)
+	}
+	if plan, err = dao.SyncIndexes(ctx, morn.SyncPlan); err != nil || !plan.InSync() {
+		t.Fatalf("Expected the indexes in sync, got %v\n%s", err, plan)
+	}
+
+	// a stale index and a declaration that drifted from the collection
+	if _, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "deleted", Value: 1}}}); err != nil {
+		t.Fatalf("CreateOne() error = %v", err)
+	}
+	ttl := int32(7200)
+	dao.Index(morn.Index{Keys: []string{"expires_at:1"}, Option: option.QueryOption{ExpireAfterSeconds: &ttl}})
+	plan, err = dao.SyncIndexes(ctx, morn.SyncCreate)
+	if err != nil {
+		t.Fatalf("SyncIndexes(SyncCreate) error = %v", err)
+	}
+	if len(plan.Stale) != 1 || plan.Stale[0] != "deleted_1" {
+		t.Errorf("Expected the stale deleted_1 index, got %v", plan.Stale)
+	}
+	if len(plan.Mismatched) != 1 || plan.Mismatched[0].Option != "expireAfterSeconds" || plan.Mismatched[0].Existing != "3600" {
+		t.Errorf("Expected the TTL mismatch, got %+v", plan.Mismatched)
+	}
+
+	if _, err := dao.SyncIndexes(ctx, morn.SyncDrop); err != nil {
+		t.Fatalf("SyncIndexes(SyncDrop) error = %v", err)
+	}
+	if plan, err = dao.SyncIndexes(ctx, morn.SyncPlan); err != nil || !plan.InSync() {
+		t.Errorf("Expected the indexes in sync after SyncDrop, got %v\n%s", err, plan)
+	}
+}
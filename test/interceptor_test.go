@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestInterceptorOrder(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	var calls []string
+	record := func(name string) clause.Interceptor {
+		return func(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+			calls = append(calls, name+" before "+op.Name)
+			err := next(ctx, op)
+			calls = append(calls, name+" after "+op.Name)
+			return err
+		}
+	}
+	ins.Use(record("instance"))
+	dao := morn.NewDao(UserCollection, User{}, ins, nil).Use(record("dao"))
+
+	if _, err := dao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com"}); err != nil {
+		t.Fatalf("MCreateOne() error = %v", err)
+	}
+
+	want := []string{"instance before insertOne", "dao before insertOne", "dao after insertOne", "instance after insertOne"}
+	if len(calls) != len(want) {
+		t.Fatalf("Expected calls %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("Expected call %d to be %q, got %q", i, want[i], calls[i])
+		}
+	}
+}
+
+func TestInterceptorModifiesOperation(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	for _, name := range []string{"user1", "user2"} {
+		if _, err := userDao.Clause().MCreateOne(&User{Username: name, Email: name + "@example.com"}); err != nil {
+			t.Fatalf("MCreateOne() error = %v", err)
+		}
+	}
+
+	var result interface{}
+	var resultErr error
+	dao := morn.NewDao(UserCollection, User{}, ins, nil).Use(func(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+		if op.Kind == clause.KindRead {
+			op.Filter = bson.M{"username": "user2"}
+		}
+		resultErr = next(ctx, op)
+		result = op.Result
+		return resultErr
+	})
+
+	var user User
+	if err := dao.Clause().Where(bson.M{"username": "user1"}).MFindOne(&user); err != nil {
+		t.Fatalf("MFindOne() error = %v", err)
+	}
+	if user.Username != "user2" {
+		t.Errorf("Expected the filter of the interceptor to find user2, got %s", user.Username)
+	}
+	if _, ok := result.(*mongo.SingleResult); !ok {
+		t.Errorf("Expected the interceptor to see a *mongo.SingleResult, got %T", result)
+	}
+
+	count, err := dao.Clause().Where(bson.M{}).MCount()
+	if err != nil {
+		t.Fatalf("MCount() error = %v", err)
+	}
+	if count != 1 || result != int64(1) {
+		t.Errorf("Expected the count of the modified filter to be 1, got %d (interceptor saw %v)", count, result)
+	}
+
+	if err := dao.Clause().Where(bson.M{"username": "missing"}).MFindOne(&user); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("Expected ErrNoDocuments, got %v", err)
+	}
+	if !errors.Is(resultErr, mongo.ErrNoDocuments) {
+		t.Errorf("Expected the interceptor to see ErrNoDocuments, got %v", resultErr)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	errRejected := errors.New("rejected")
+	dao := morn.NewDao(UserCollection, User{}, ins, nil).Use(func(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+		switch op.Name {
+		case "findOne":
+			op.Result = mongo.NewSingleResultFromDocument(bson.M{"username": "cached"}, nil, nil)
+			return nil
+		case "deleteMany":
+			return errRejected
+		}
+		return next(ctx, op)
+	})
+
+	var user User
+	if err := dao.Clause().MFindOne(&user); err != nil {
+		t.Fatalf("MFindOne() error = %v", err)
+	}
+	if user.Username != "cached" {
+		t.Errorf("Expected the result of the interceptor, got %q", user.Username)
+	}
+
+	if _, err := dao.Clause().MDeleteMany(); !errors.Is(err, errRejected) {
+		t.Errorf("Expected the interceptor to reject MDeleteMany, got %v", err)
+	}
+
+	if _, err := dao.Clause().FindMany(); err != nil {
+		t.Errorf("Expected FindMany to reach the database, got %v", err)
+	}
+}
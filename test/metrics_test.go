@@ -0,0 +1,277 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func findMetric(rm metricdata.ResourceMetrics, name string) (metricdata.Metrics, bool) {
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				return m, true
+			}
+		}
+	}
+	return metricdata.Metrics{}, false
+}
+
+func sumOf(m metricdata.Metrics) int64 {
+	var total int64
+	if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+		for _, point := range sum.DataPoints {
+			total += point.Value
+		}
+	}
+	return total
+}
+
+func TestMetrics(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	metrics, reader, err := morn.NewManualMetrics()
+	if err != nil {
+		t.Fatalf("NewManualMetrics() error = %v", err)
+	}
+	if err := ins.SetMetrics(metrics); err != nil {
+		t.Fatalf("SetMetrics() error = %v", err)
+	}
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Fatalf("GenIDForDao() error = %v", err)
+	}
+	users := []User{
+		{Username: "user1", Email: "user1@example.com", UserID: userID},
+		{Username: "user2", Email: "user2@example.com", UserID: userID + 1},
+	}
+	if _, err := userDao.Clause().MCreateMany(users); err != nil {
+		t.Fatalf("MCreateMany() error = %v", err)
+	}
+	if _, err := userDao.Clause().Where(bson.M{"user_id": bson.M{"$gte": userID}}).MCount(); err != nil {
+		t.Fatalf("MCount() error = %v", err)
+	}
+	var found []User
+	if err := userDao.Clause().Where(bson.M{"user_id": bson.M{"$gte": userID}}).MFindMany(&found); err != nil {
+		t.Fatalf("MFindMany() error = %v", err)
+	}
+	if err := userDao.Clause().Where(bson.M{"user_id": -1}).MFindOne(&User{}); err == nil {
+		t.Fatal("Expected no document")
+	}
+	if _, err := userDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: userID}); err == nil {
+		t.Fatal("Expected a duplicate key error")
+	}
+	err = userDao.Session(context.Background(), func(ctx context.Context) error {
+		return errors.New("abort")
+	}, nil)
+	if err == nil {
+		t.Fatal("Expected the session to fail")
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	duration, ok := findMetric(rm, "db.client.operation.duration")
+	if !ok {
+		t.Fatal("Expected the operation duration histogram")
+	}
+	if histogram, ok := duration.Data.(metricdata.Histogram[float64]); !ok || len(histogram.DataPoints) < 3 {
+		t.Errorf("Expected a data point per collection and operation, got %+v", duration.Data)
+	}
+
+	counters := map[string]int64{
+		"morn.documents.modified": 2,
+		"morn.documents.returned": 2,
+		"morn.operation.errors":   2,
+		"morn.transactions":       1,
+	}
+	for name, want := range counters {
+		m, ok := findMetric(rm, name)
+		if !ok {
+			t.Errorf("Expected the %s counter", name)
+			continue
+		}
+		if got := sumOf(m); got != want {
+			t.Errorf("Expected %s = %d, got %d", name, want, got)
+		}
+	}
+
+	for _, name := range []string{"morn.genid.duration", "db.client.connection.count"} {
+		if _, ok := findMetric(rm, name); !ok {
+			t.Errorf("Expected the %s metric", name)
+		}
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: ""},
+		{err: context.Canceled, want: morn.ErrorClassCanceled},
+		{err: context.DeadlineExceeded, want: morn.ErrorClassTimeout},
+		{err: fmt.Errorf("users: %w", morn.ErrCircuitOpen), want: morn.ErrorClassCircuitOpen},
+		{err: morn.ErrBulkheadFull, want: morn.ErrorClassBulkheadFull},
+		{err: clause.ErrReadOnly, want: morn.ErrorClassRejected},
+		{err: mongo.ErrNoDocuments, want: morn.ErrorClassNotFound},
+		{err: mongo.ErrClientDisconnected, want: morn.ErrorClassUnavailable},
+		{err: mongo.CommandError{Code: 11000}, want: morn.ErrorClassDuplicateKey},
+		{err: mongo.CommandError{Code: 2}, want: morn.ErrorClassServer},
+		{err: errors.New("invalid"), want: morn.ErrorClassClient},
+	}
+	for _, tt := range tests {
+		if got := morn.ErrorClass(tt.err); got != tt.want {
+			t.Errorf("ErrorClass(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMetricsBreaker(t *testing.T) {
+	unreachable, err := mongo.Connect(options.Client().
+		SetHosts([]string{"localhost:1"}).
+		SetServerSelectionTimeout(50 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer unreachable.Disconnect(context.Background())
+
+	metrics, reader, err := morn.NewManualMetrics()
+	if err != nil {
+		t.Fatalf("NewManualMetrics() error = %v", err)
+	}
+	ins, err := morn.FromClient(unreachable, morn.WithDatabase("Cluster0"), morn.WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("FromClient() error = %v", err)
+	}
+
+	opt := ins.GetOptsField()
+	opt.Breaker = &option.BreakerOption{MinRequests: 1, OpenTimeout: time.Minute}
+	downDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+	if _, err := downDao.Clause().MCount(); err == nil {
+		t.Fatal("Expected a server selection error")
+	}
+	if _, err := downDao.Clause().MCount(); !errors.Is(err, morn.ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	counters := map[string]int64{
+		"morn.breaker.transitions": 1,
+		"morn.operation.errors":    2,
+	}
+	for name, want := range counters {
+		m, ok := findMetric(rm, name)
+		if !ok {
+			t.Errorf("Expected the %s counter", name)
+			continue
+		}
+		if got := sumOf(m); got != want {
+			t.Errorf("Expected %s = %d, got %d", name, want, got)
+		}
+	}
+
+	// replacing or removing the metrics stops observing the pools
+	var stopped int
+	if err := ins.SetMetrics(stopCountingMetrics{metrics, &stopped}); err != nil {
+		t.Fatalf("SetMetrics() error = %v", err)
+	}
+	if err := ins.SetMetrics(nil); err != nil {
+		t.Fatalf("SetMetrics(nil) error = %v", err)
+	}
+	if stopped != 1 {
+		t.Errorf("Expected the pools to be released once, got %d", stopped)
+	}
+}
+
+func TestMetricsReturned(t *testing.T) {
+	unreachable, err := mongo.Connect(options.Client().
+		SetHosts([]string{"localhost:1"}).
+		SetServerSelectionTimeout(50 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer unreachable.Disconnect(context.Background())
+
+	metrics, reader, err := morn.NewManualMetrics()
+	if err != nil {
+		t.Fatalf("NewManualMetrics() error = %v", err)
+	}
+	ins, err := morn.FromClient(unreachable, morn.WithDatabase("Cluster0"), morn.WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("FromClient() error = %v", err)
+	}
+
+	// the reads are completed by an interceptor, as if the server returned the documents
+	userDao := morn.NewDao(UserCollection, User{}, ins, nil).Use(func(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+		switch op.Name {
+		case "find":
+			docs := []interface{}{bson.M{"user_id": 1}, bson.M{"user_id": 2}, bson.M{"user_id": 3}}
+			cursor, err := mongo.NewCursorFromDocuments(docs, nil, nil)
+			op.Result = cursor
+			return err
+		case "findOne":
+			op.Result = mongo.NewSingleResultFromDocument(bson.M{"user_id": 1}, nil, nil)
+		case "countDocuments":
+			op.Result = int64(5)
+		}
+		return nil
+	})
+	var users []User
+	if err := userDao.Clause().MFindMany(&users); err != nil || len(users) != 3 {
+		t.Fatalf("MFindMany() = %d users, error = %v", len(users), err)
+	}
+	if err := userDao.Clause().MFindOne(&User{}); err != nil {
+		t.Fatalf("MFindOne() error = %v", err)
+	}
+	if _, err := userDao.Clause().MCount(); err != nil {
+		t.Fatalf("MCount() error = %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	m, ok := findMetric(rm, "morn.documents.returned")
+	if !ok {
+		t.Fatal("Expected the morn.documents.returned counter")
+	}
+	if got := sumOf(m); got != 4 {
+		t.Errorf("Expected 4 returned documents, got %d", got)
+	}
+}
+
+// stopCountingMetrics counts the pool observations stopped by the Instance
+type stopCountingMetrics struct {
+	*morn.OtelMetrics
+	stopped *int
+}
+
+func (m stopCountingMetrics) ObservePools(read func() []morn.PoolHealth) (func() error, error) {
+	stop, err := m.OtelMetrics.ObservePools(read)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		*m.stopped++
+		return stop()
+	}, nil
+}
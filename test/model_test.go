@@ -1,10 +1,11 @@
 package test
 
 import (
+	"context"
+	"testing"
 	"time"
 
 	"github.com/nghialthanh/morn-go"
-	"github.com/nghialthanh/morn-go/option"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
@@ -16,22 +17,19 @@ type User struct {
 	ID        *bson.ObjectID `bson:"_id,omitempty"`
 	CreatedAt *time.Time     `bson:"created_at"`
 	UpdatedAt *time.Time     `bson:"updated_at"`
-	Username  string         `bson:"username"`
-	UserID    int64          `bson:"user_id"`
+	Username  string         `bson:"username" morn:"index:username_1_email_1"`
+	UserID    int64          `bson:"user_id" morn:"index,unique"`
 	Password  string         `bson:"password"`
-	Email     string         `bson:"email"`
+	Email     string         `bson:"email" morn:"index:username_1_email_1"`
 	Point     int64          `bson:"point"`
 }
 
-func InitUserModel(ins *morn.Instance) *morn.Dao {
+func InitUserModel(t *testing.T, ins *morn.Instance) *morn.Dao {
+	t.Helper()
 	dao := morn.NewDao(UserCollection, User{}, ins, nil)
 
-	enumTrue := true
-	dao.Clause().Option(option.QueryOption{
-		Unique: &enumTrue,
-	}).CreateIndex("user_id:1")
-
-	//composite index
-	dao.Clause().CreateIndex("username:1", "email:1")
+	if _, err := dao.SyncIndexes(context.Background(), morn.SyncCreate); err != nil {
+		t.Fatalf("SyncIndexes() error = %v", err)
+	}
 	return dao
 }
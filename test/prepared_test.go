@@ -0,0 +1,159 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestPreparedBind(t *testing.T) {
+	prepared, err := dryRunClause(t).Where(bson.M{
+		"user_id": clause.Param[int64]("id"),
+		"point":   bson.M{"$gte": 10},
+		"$or":     bson.A{bson.M{"email": clause.Param[string]("email")}, bson.M{"username": "admin"}},
+	}).Sort("point:desc").Limit(5).Prepare()
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	if params := prepared.Params(); len(params) != 2 || params[0] != "email" || params[1] != "id" {
+		t.Errorf("Params() = %v", params)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			c, err := prepared.Bind(clause.Params{"id": id, "email": fmt.Sprintf("user%d@example.com", id)})
+			if err != nil {
+				t.Errorf("Bind() error = %v", err)
+				return
+			}
+			var users []User
+			if err := c.MFindMany(&users); !errors.Is(err, clause.ErrDryRun) {
+				t.Errorf("MFindMany() error = %v, want ErrDryRun", err)
+				return
+			}
+			statement := c.Statement()
+			filter, _ := statement.Filter.(bson.M)
+			or, _ := filter["$or"].(bson.A)
+			if filter["user_id"] != id || len(or) != 2 || or[0].(bson.M)["email"] != fmt.Sprintf("user%d@example.com", id) {
+				t.Errorf("Unexpected filter %v", statement.Filter)
+			}
+			shell, _ := statement.Mongosh()
+			want := `{"sort": {"point": -1}, "limit": NumberLong("5")}`
+			if len(shell) < len(want) || shell[len(shell)-len(want)-1:len(shell)-1] != want {
+				t.Errorf("Expected the prepared options, got %s", shell)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	invalid := []clause.Params{
+		{"id": int64(1)},
+		{"id": 1, "email": "user1@example.com"},
+		{"id": int64(1), "email": "user1@example.com", "name": "user1"},
+		{"id": nil, "email": "user1@example.com"},
+	}
+	for _, params := range invalid {
+		if _, err := prepared.Bind(params); !errors.Is(err, clause.ErrInvalidParams) {
+			t.Errorf("Bind(%v) error = %v, want ErrInvalidParams", params, err)
+		}
+	}
+
+	_, err = dryRunClause(t).Where(bson.M{"a": clause.Param[int]("x"), "b": clause.Param[string]("x")}).Prepare()
+	if err == nil {
+		t.Error("Expected an error for a parameter declared with two types")
+	}
+	c := dryRunClause(t)
+	c.Where(bson.M{"user_id": clause.Param[int64]("id")}).MFindOne(&User{})
+	if _, err := c.Statement().ExtJSON(); err == nil {
+		t.Error("Expected an error for an unbound parameter")
+	}
+}
+
+func TestPreparedIsolation(t *testing.T) {
+	point := bson.M{"$gte": 10}
+	prepared, err := dryRunClause(t).Where(bson.M{"user_id": clause.Param[int64]("id"), "point": point}).Sort("point:desc").Prepare()
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	point["$gte"] = 20
+
+	c, err := prepared.Bind(clause.Params{"id": int64(1)})
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if err := c.MFindOne(&User{}); !errors.Is(err, clause.ErrDryRun) {
+		t.Fatalf("MFindOne() error = %v, want ErrDryRun", err)
+	}
+	filter, _ := c.Statement().Filter.(bson.M)
+	if gte, _ := filter["point"].(bson.M); gte["$gte"] != 10 {
+		t.Errorf("Expected the filter as prepared, got %v", filter)
+	}
+
+	c, err = prepared.Bind(clause.Params{"id": int64(1)})
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if err := c.Limit(3).MFindMany(&[]User{}); !errors.Is(err, clause.ErrDryRun) {
+		t.Fatalf("MFindMany() error = %v, want ErrDryRun", err)
+	}
+	if shell, _ := c.Statement().Mongosh(); !strings.Contains(shell, `"limit": NumberLong("3")`) {
+		t.Errorf("Expected the limit set after Bind, got %s", shell)
+	}
+}
+
+func TestPrepared(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	users := []User{
+		{Username: "user1", Email: "user1@example.com", UserID: 1, Point: 10},
+		{Username: "user2", Email: "user2@example.com", UserID: 2, Point: 20},
+		{Username: "user3", Email: "user3@example.com", UserID: 3, Point: 30},
+	}
+	if _, err := userDao.Clause().MCreateMany(users); err != nil {
+		t.Fatalf("MCreateMany() error = %v", err)
+	}
+
+	prepared, err := userDao.Clause().Where(bson.M{"point": bson.M{"$gte": clause.Param[int64]("min")}}).Sort("point:desc").Limit(2).Prepare()
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	for _, tc := range []struct {
+		min  int64
+		want []int64
+	}{{10, []int64{3, 2}}, {25, []int64{3}}, {40, nil}} {
+		c, err := prepared.Bind(clause.Params{"min": tc.min})
+		if err != nil {
+			t.Fatalf("Bind() error = %v", err)
+		}
+		var found []User
+		if err := c.MFindMany(&found); err != nil {
+			t.Fatalf("MFindMany() error = %v", err)
+		}
+		if len(found) != len(tc.want) {
+			t.Fatalf("min %d: expected %d users, got %d", tc.min, len(tc.want), len(found))
+		}
+		for i, user := range found {
+			if user.UserID != tc.want[i] {
+				t.Errorf("min %d: expected user %d at %d, got %d", tc.min, tc.want[i], i, user.UserID)
+			}
+		}
+	}
+
+	c, err := prepared.BindTo(userDao.Clause(), clause.Params{"min": int64(15)})
+	if err != nil {
+		t.Fatalf("BindTo() error = %v", err)
+	}
+	if count, err := c.MCount(); err != nil || count != 2 {
+		t.Errorf("MCount() = %d, %v, want 2", count, err)
+	}
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
+)
+
+func TestReadWriteConcern(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	opt := ins.GetOptsField()
+	opt.ReadPreference = readpref.SecondaryPreferred()
+	opt.WriteConcern = writeconcern.Majority()
+	analyticsDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+
+	if rp := analyticsDao.GetOptionField().ReadPreference; rp == nil || rp.Mode() != readpref.SecondaryPreferredMode {
+		t.Errorf("Dao read preference = %v, want %v", rp, readpref.SecondaryPreferredMode)
+	}
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Errorf("Failed to generate user ID: %v", err)
+	}
+
+	_, err = userDao.Clause().WithWriteConcern(writeconcern.Majority()).MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: userID})
+	if err != nil {
+		t.Fatalf("MCreateOne() with majority write concern error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		clause func(ctx context.Context) (int64, error)
+		// secondary reads may not see the insert yet
+		secondary bool
+	}{
+		{
+			name:      "Dao read preference",
+			secondary: true,
+			clause: func(ctx context.Context) (int64, error) {
+				return analyticsDao.Ctx(ctx).MCount()
+			},
+		},
+		{
+			name: "Clause read preference and read concern",
+			clause: func(ctx context.Context) (int64, error) {
+				return userDao.Ctx(ctx).ReadFrom(readpref.PrimaryPreferred()).WithReadConcern(readconcern.Majority()).MCount()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := tt.clause(context.Background())
+			if err != nil {
+				t.Fatalf("MCount() error = %v", err)
+			}
+			if count != 1 && !tt.secondary {
+				t.Errorf("MCount() = %d, want 1", count)
+			}
+
+			// Inside a transaction the transaction settings take precedence
+			err = userDao.Session(context.Background(), func(ctx context.Context) error {
+				_, err := tt.clause(ctx)
+				return err
+			}, &option.SessionOption{ReadPreference: readpref.Primary()})
+			if err != nil {
+				t.Errorf("MCount() inside transaction error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const RegistryCollection = "registry_users"
+
+func TestRegisterAndMigrate(t *testing.T) {
+	ins := setupTestDB(t)
+	defer ins.GetDB().Collection(RegistryCollection).Drop(context.Background())
+
+	unique := true
+	registry, err := morn.FromClient(ins.GetClient(), morn.WithMornOption(ins.GetOptsField()))
+	if err != nil {
+		t.Fatalf("FromClient() error = %v", err)
+	}
+	err = registry.Register(morn.Model{
+		Collection: RegistryCollection,
+		Template:   User{},
+		Indexes: []morn.Index{
+			{Keys: []string{"user_id:1"}, Option: option.QueryOption{Unique: &unique}},
+			{Keys: []string{"username:1", "email:1"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := registry.Register(morn.Model{Collection: "other_users", Template: &User{}}); err == nil {
+		t.Error("Expected an error when registering the same type twice")
+	}
+	if dao := morn.DaoOf[User](registry); dao != nil {
+		t.Error("Expected no Dao before the database is set")
+	}
+
+	registry.SetDB("Cluster0")
+	userDao := morn.DaoOf[User](registry)
+	if userDao == nil || userDao != registry.DaoByName(RegistryCollection) {
+		t.Fatalf("Expected DaoOf and DaoByName to return the registered Dao")
+	}
+	if models := registry.Models(); len(models) != 1 || models[0].Collection != RegistryCollection {
+		t.Errorf("Models() = %v", models)
+	}
+
+	// Migrate is idempotent
+	for n := 0; n < 2; n++ {
+		if err := registry.Migrate(context.Background()); err != nil {
+			t.Fatalf("Migrate() error = %v", err)
+		}
+	}
+
+	cursor, err := userDao.Col().Indexes().List(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to list indexes: %v", err)
+	}
+	var indexes []bson.M
+	if err := cursor.All(context.Background(), &indexes); err != nil {
+		t.Fatalf("Failed to decode indexes: %v", err)
+	}
+	if len(indexes) != 3 {
+		t.Errorf("Expected _id and the 2 registered indexes, got %d", len(indexes))
+	}
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Fatalf("GenIDForDao() error = %v", err)
+	}
+	if userID < ins.GetOptsField().DefaultNumber {
+		t.Errorf("Expected the sequence to start at %d, got %d", ins.GetOptsField().DefaultNumber, userID)
+	}
+}
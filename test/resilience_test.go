@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	unreachable, err := mongo.Connect(options.Client().
+		SetHosts([]string{"localhost:1"}).
+		SetServerSelectionTimeout(50 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer unreachable.Disconnect(context.Background())
+	if err := ins.AddConnection("down", unreachable.Database("Cluster0")); err != nil {
+		t.Fatalf("AddConnection() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var events []morn.BreakerEvent
+	ins.OnBreakerStateChange(func(e morn.BreakerEvent) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	})
+
+	opt := ins.GetOptsField()
+	opt.ReadConnection = "down"
+	opt.Breaker = &option.BreakerOption{FailureRate: 0.5, MinRequests: 3, OpenTimeout: 200 * time.Millisecond, HalfOpenRequests: 1}
+	downDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+
+	for i := 0; i < 3; i++ {
+		if _, err := downDao.Clause().MCount(); err == nil || errors.Is(err, morn.ErrCircuitOpen) {
+			t.Fatalf("Expected a server selection error, got %v", err)
+		}
+	}
+	if state := downDao.BreakerState(); state != morn.BreakerOpen {
+		t.Fatalf("Expected the breaker to be open, got %s", state)
+	}
+
+	start := time.Now()
+	if _, err := downDao.Clause().MCount(); !errors.Is(err, morn.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if time.Since(start) > 20*time.Millisecond {
+		t.Errorf("Expected an open breaker to fail fast, took %s", time.Since(start))
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if _, err := downDao.Clause().MCount(); err == nil || errors.Is(err, morn.ErrCircuitOpen) {
+		t.Errorf("Expected the half-open probe to reach the driver, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []morn.BreakerState{morn.BreakerOpen, morn.BreakerHalfOpen, morn.BreakerOpen}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d breaker events, got %v", len(want), events)
+	}
+	for i, state := range want {
+		if events[i].To != state {
+			t.Errorf("Event %d: expected %s, got %s", i, state, events[i].To)
+		}
+	}
+}
+
+func TestBulkhead(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	if _, err := userDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com"}); err != nil {
+		t.Fatalf("MCreateOne() error = %v", err)
+	}
+
+	opt := ins.GetOptsField()
+	opt.MaxConcurrent = 1
+	limitedDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+
+	done := make(chan error)
+	go func() {
+		_, err := limitedDao.Clause().Where(bson.M{"$where": "sleep(500) || true"}).MCount()
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := limitedDao.Clause().MCount(); !errors.Is(err, morn.ErrBulkheadFull) {
+		t.Errorf("Expected ErrBulkheadFull, got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Slow MCount() error = %v", err)
+	}
+	if _, err := limitedDao.Clause().MCount(); err != nil {
+		t.Errorf("Expected the slot to be released, got %v", err)
+	}
+}
+
+func TestBreakerIgnoresBulkheadRejections(t *testing.T) {
+	unreachable, err := mongo.Connect(options.Client().
+		SetHosts([]string{"localhost:1"}).
+		SetServerSelectionTimeout(300 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer unreachable.Disconnect(context.Background())
+	ins, err := morn.FromClient(unreachable, morn.WithDatabase("Cluster0"))
+	if err != nil {
+		t.Fatalf("FromClient() error = %v", err)
+	}
+
+	opt := ins.GetOptsField()
+	opt.Breaker = &option.BreakerOption{FailureRate: 0.5, MinRequests: 1, OpenTimeout: 50 * time.Millisecond, HalfOpenRequests: 2}
+	opt.MaxConcurrent = 1
+	downDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+
+	if _, err := downDao.Clause().MCount(); err == nil || errors.Is(err, morn.ErrCircuitOpen) {
+		t.Fatalf("Expected a server selection error, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// the first probe holds the only slot of the bulkhead
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		downDao.Clause().MCount()
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, err := downDao.Clause().MCount(); !errors.Is(err, morn.ErrBulkheadFull) {
+			t.Errorf("Expected ErrBulkheadFull while the probe runs, got %v", err)
+		}
+	}
+	if state := downDao.BreakerState(); state != morn.BreakerHalfOpen {
+		t.Errorf("Expected bulkhead rejections to leave the breaker half-open, got %s", state)
+	}
+	<-done
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Not primary", err: mongo.CommandError{Code: 10107}, want: true},
+		{name: "Primary stepped down", err: mongo.CommandError{Code: 189}, want: true},
+		{name: "Network error", err: mongo.CommandError{Labels: []string{"NetworkError"}}, want: true},
+		{name: "Retryable write label", err: mongo.CommandError{Code: 1, Labels: []string{"RetryableWriteError"}}, want: true},
+		{name: "Duplicate key", err: mongo.CommandError{Code: 11000}, want: false},
+		{name: "Deadline", err: context.DeadlineExceeded, want: false},
+		{name: "Client disconnected", err: mongo.ErrClientDisconnected, want: false},
+		{name: "Other", err: errors.New("boom"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clause.Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// failCommand makes the next times runs of command fail with NotWritablePrimary
+// The test is skipped when the server does not enable test commands
+func failCommand(t *testing.T, ins *morn.Instance, command string, times int) {
+	t.Helper()
+	err := ins.GetClient().Database("admin").RunCommand(context.Background(), bson.D{
+		{Key: "configureFailPoint", Value: "failCommand"},
+		{Key: "mode", Value: bson.M{"times": times}},
+		{Key: "data", Value: bson.M{"failCommands": bson.A{command}, "errorCode": 10107}},
+	}).Err()
+	if err != nil {
+		t.Skipf("failCommand fail point is not available: %v", err)
+	}
+	t.Cleanup(func() {
+		ins.GetClient().Database("admin").RunCommand(context.Background(), bson.D{
+			{Key: "configureFailPoint", Value: "failCommand"},
+			{Key: "mode", Value: "off"},
+		})
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Fatalf("Failed to generate user ID: %v", err)
+	}
+	if _, err := userDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: userID}); err != nil {
+		t.Fatalf("MCreateOne() error = %v", err)
+	}
+
+	policy := &option.RetryPolicy{MaxAttempts: 4, InitialBackoff: 10 * time.Millisecond}
+	opt := ins.GetOptsField()
+	opt.Retry = policy
+	retryDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+
+	// The driver retries once by itself, the policy handles the remaining failures
+	t.Run("Read retried", func(t *testing.T) {
+		failCommand(t, ins, "find", 3)
+		var users []User
+		if err := retryDao.Clause().Where(bson.M{"user_id": userID}).MFindMany(&users); err != nil {
+			t.Fatalf("MFindMany() error = %v", err)
+		}
+		if len(users) != 1 {
+			t.Errorf("Expected 1 user, got %d", len(users))
+		}
+	})
+
+	t.Run("Retry disabled by clause", func(t *testing.T) {
+		failCommand(t, ins, "find", 3)
+		var users []User
+		if err := retryDao.Clause().Retry(nil).MFindMany(&users); err == nil {
+			t.Error("Expected MFindMany() to fail without retries")
+		}
+	})
+
+	t.Run("Idempotent update retried", func(t *testing.T) {
+		failCommand(t, ins, "update", 3)
+		if err := retryDao.Clause().Where(bson.M{"user_id": userID}).MUpdateOne(bson.M{"email": "retry@example.com"}); err != nil {
+			t.Errorf("MUpdateOne() error = %v", err)
+		}
+	})
+
+	t.Run("Non idempotent update not retried", func(t *testing.T) {
+		failCommand(t, ins, "findAndModify", 3)
+		if err := retryDao.Clause().Where(bson.M{"user_id": userID}).MIncreaseValue(nil, "user_id:0", false); err == nil {
+			t.Error("Expected $inc not to be retried")
+		}
+	})
+
+	t.Run("Non idempotent update retried when opted in", func(t *testing.T) {
+		failCommand(t, ins, "findAndModify", 3)
+		optIn := *policy
+		optIn.RetryNonIdempotent = true
+		if err := retryDao.Clause().Retry(&optIn).Where(bson.M{"user_id": userID}).MIncreaseValue(nil, "user_id:0", false); err != nil {
+			t.Errorf("MIncreaseValue() error = %v", err)
+		}
+	})
+}
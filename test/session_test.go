@@ -3,12 +3,15 @@ package test
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
+
+	"github.com/nghialthanh/morn-go"
 )
 
 func TestSession(t *testing.T) {
 	ins := setupTestDB(t)
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 	defer cleanupTestDB(t, userDao, ins)
 
 	// Create test data
@@ -122,3 +125,86 @@ func TestSession(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionCallbacks(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	tests := []struct {
+		name         string
+		fn           func(ctx context.Context) error
+		wantErr      bool
+		wantCommit   []int
+		wantRollback []int
+	}{
+		{
+			name: "Commit callbacks run in order",
+			fn: func(ctx context.Context) error {
+				return nil
+			},
+			wantErr:      false,
+			wantCommit:   []int{1, 2},
+			wantRollback: nil,
+		},
+		{
+			name: "Rollback callbacks run after error",
+			fn: func(ctx context.Context) error {
+				return errors.New("custom error")
+			},
+			wantErr:      true,
+			wantCommit:   nil,
+			wantRollback: []int{1, 2},
+		},
+		{
+			name: "Failing callback does not fail transaction",
+			fn: func(ctx context.Context) error {
+				return morn.OnCommit(ctx, func(ctx context.Context) error {
+					panic("callback panic")
+				})
+			},
+			wantErr:      false,
+			wantCommit:   []int{1, 2},
+			wantRollback: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var committed, rolledBack []int
+			err := userDao.Session(context.Background(), func(ctx context.Context) error {
+				for _, i := range []int{1, 2} {
+					i := i
+					if err := morn.OnCommit(ctx, func(ctx context.Context) error {
+						committed = append(committed, i)
+						return nil
+					}); err != nil {
+						return err
+					}
+					if err := morn.OnRollback(ctx, func(ctx context.Context) error {
+						rolledBack = append(rolledBack, i)
+						return errors.New("ignored error")
+					}); err != nil {
+						return err
+					}
+				}
+				return tt.fn(ctx)
+			}, nil)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Session() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(committed, tt.wantCommit) {
+				t.Errorf("commit callbacks = %v, want %v", committed, tt.wantCommit)
+			}
+			if !reflect.DeepEqual(rolledBack, tt.wantRollback) {
+				t.Errorf("rollback callbacks = %v, want %v", rolledBack, tt.wantRollback)
+			}
+		})
+	}
+
+	if err := morn.OnCommit(context.Background(), func(ctx context.Context) error { return nil }); err != morn.ErrNoSession {
+		t.Errorf("OnCommit() outside session error = %v, want %v", err, morn.ErrNoSession)
+	}
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/logger"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func TestNewValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []morn.Option
+	}{
+		{
+			name: "No deployment",
+			opts: []morn.Option{morn.WithDatabase("Cluster0")},
+		},
+		{
+			name: "Empty URI",
+			opts: []morn.Option{morn.WithURI("")},
+		},
+		{
+			name: "Invalid URI",
+			opts: []morn.Option{morn.WithURI("http://localhost")},
+		},
+		{
+			name: "Empty database",
+			opts: []morn.Option{morn.WithURI("mongodb://localhost:27017"), morn.WithDatabase("")},
+		},
+		{
+			name: "Nil logger",
+			opts: []morn.Option{morn.WithURI("mongodb://localhost:27017"), morn.WithLogger(nil)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ins, err := morn.New(context.Background(), tt.opts...)
+			if err == nil {
+				t.Errorf("New() error = nil, want error")
+			}
+			if ins != nil {
+				t.Errorf("New() returned an instance on error")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	url := ""
+	ins, err := morn.New(context.Background(),
+		morn.WithURI(url),
+		morn.WithDatabase("Cluster0"),
+		morn.WithMornOption(option.MornOption{CreateAtField: "created_at"}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer ins.GetClient().Disconnect(context.TODO())
+
+	if err := ins.GetClient().Ping(context.Background(), nil); err != nil {
+		t.Errorf("Expected New to return a connected client, got %v", err)
+	}
+	if ins.GetDB() == nil || ins.GetDB().Name() != "Cluster0" {
+		t.Errorf("New() did not select the database")
+	}
+	if ins.GetOptsField().CreateAtField != "created_at" {
+		t.Errorf("New() did not keep the dao options")
+	}
+	if ins.GetLogger() == nil {
+		t.Errorf("New() did not set a default logger")
+	}
+}
+
+func TestFromClient(t *testing.T) {
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Disconnect(context.TODO())
+
+	if _, err := morn.FromClient(nil); err == nil {
+		t.Errorf("FromClient(nil) error = nil, want error")
+	}
+	if _, err := morn.FromClient(client, morn.WithURI("mongodb://localhost:27017")); err == nil {
+		t.Errorf("FromClient() with URI error = nil, want error")
+	}
+
+	log := logger.NewFmtLogger()
+	ins, err := morn.FromClient(client,
+		morn.WithLogger(log),
+		morn.WithDatabase("Cluster0"),
+		morn.WithMornOption(option.MornOption{CreateAtField: "created_at"}),
+	)
+	if err != nil {
+		t.Fatalf("FromClient() error = %v", err)
+	}
+	if ins.GetClient() != client {
+		t.Errorf("FromClient() did not keep the client")
+	}
+	if ins.GetLogger() != log {
+		t.Errorf("FromClient() did not keep the logger")
+	}
+	if ins.GetDB() == nil || ins.GetDB().Name() != "Cluster0" {
+		t.Errorf("FromClient() did not select the database")
+	}
+	if ins.GetOptsField().CreateAtField != "created_at" {
+		t.Errorf("FromClient() did not keep the dao options")
+	}
+}
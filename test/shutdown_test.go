@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+)
+
+func TestShutdown(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+
+	entered := make(chan struct{})
+	proceed := make(chan struct{})
+	sessionErr := make(chan error, 1)
+	go func() {
+		sessionErr <- userDao.Session(context.Background(), func(ctx context.Context) error {
+			close(entered)
+			<-proceed
+			_, err := userDao.Ctx(ctx).MCount()
+			return err
+		}, nil)
+	}()
+	<-entered
+
+	type result struct {
+		report *morn.ShutdownReport
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		report, err := ins.Shutdown(ctx)
+		done <- result{report, err}
+	}()
+
+	// wait until the instance refuses new work
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := userDao.Clause().MCount()
+		if errors.Is(err, morn.ErrShuttingDown) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("MCount() during shutdown error = %v, want %v", err, morn.ErrShuttingDown)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := userDao.GenIDForDao(); !errors.Is(err, morn.ErrShuttingDown) {
+		t.Errorf("GenIDForDao() during shutdown error = %v, want %v", err, morn.ErrShuttingDown)
+	}
+
+	close(proceed)
+	if err := <-sessionErr; err != nil {
+		t.Errorf("Session() opened before shutdown error = %v", err)
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Shutdown() error = %v", res.err)
+	}
+	if res.report.DrainedSessions != 1 || res.report.AbortedTransactions != 0 {
+		t.Errorf("Shutdown() report = %+v, want 1 drained session", res.report)
+	}
+	if _, err := ins.Shutdown(context.Background()); !errors.Is(err, morn.ErrShuttingDown) {
+		t.Errorf("second Shutdown() error = %v, want %v", err, morn.ErrShuttingDown)
+	}
+}
+
+func TestShutdownAbortsTransactions(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+
+	entered := make(chan struct{})
+	sessionErr := make(chan error, 1)
+	go func() {
+		sessionErr <- userDao.Session(context.Background(), func(ctx context.Context) error {
+			if _, err := userDao.Ctx(ctx).MCreateOne(&User{Username: "user1", UserID: 1}); err != nil {
+				return err
+			}
+			close(entered)
+			// the transaction outlives the drain timeout, Shutdown cancels its context
+			<-ctx.Done()
+			return ctx.Err()
+		}, nil)
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	report, err := ins.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if err := <-sessionErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Session() error = %v, want %v", err, context.Canceled)
+	}
+	// the insert of the aborted transaction is rolled back, there is nothing to clean up
+	if report.DrainedSessions != 0 || report.AbortedTransactions != 1 {
+		t.Errorf("Shutdown() report = %+v, want 1 aborted transaction", report)
+	}
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// captureLogger keeps the Warn records, the other levels are dropped
+type captureLogger struct {
+	mu      sync.Mutex
+	records map[string][]interface{}
+}
+
+func (l *captureLogger) Info(msg string, args ...interface{})      {}
+func (l *captureLogger) Infof(format string, args ...interface{})  {}
+func (l *captureLogger) Error(msg string, args ...interface{})     {}
+func (l *captureLogger) Errorf(format string, args ...interface{}) {}
+func (l *captureLogger) Debug(msg string, args ...interface{})     {}
+func (l *captureLogger) Debugf(format string, args ...interface{}) {}
+func (l *captureLogger) Warnf(format string, args ...interface{}) {
+	l.Warn(fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Warn(msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.records == nil {
+		l.records = make(map[string][]interface{})
+	}
+	l.records[msg] = args
+}
+
+func (l *captureLogger) get(msg string) ([]interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	args, ok := l.records[msg]
+	return args, ok
+}
+
+func attr(args []interface{}, key string) interface{} {
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == key {
+			return args[i+1]
+		}
+	}
+	return nil
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	log := &captureLogger{}
+	slowIns, err := morn.FromClient(ins.GetClient(), morn.WithLogger(log), morn.WithDatabase("Cluster0"))
+	if err != nil {
+		t.Fatalf("FromClient() error = %v", err)
+	}
+	opt := ins.GetOptsField()
+	opt.SlowQueryThreshold = time.Nanosecond
+	opt.ExplainSlowQueries = true
+	slowDao := morn.NewDao(UserCollection, User{}, slowIns, &opt)
+
+	if _, err := userDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: 1}); err != nil {
+		t.Fatalf("MCreateOne() error = %v", err)
+	}
+	var users []User
+	err = slowDao.Clause().Where(bson.M{"user_id": bson.M{"$gte": 1}}).Sort("user_id:desc").MFindMany(&users)
+	if err != nil {
+		t.Fatalf("MFindMany() error = %v", err)
+	}
+
+	args, ok := log.get("Slow query")
+	if !ok {
+		t.Fatal("Expected the slow query to be logged")
+	}
+	if got := attr(args, "query"); got != `{"user_id":{"$gte":"?"}}` {
+		t.Errorf("Expected the query shape, got %v", got)
+	}
+	if got := attr(args, "sort"); got != `{"user_id":-1}` {
+		t.Errorf("Expected the sort, got %v", got)
+	}
+	if caller, _ := attr(args, "caller").(string); !strings.Contains(caller, "slowquery_test.go") {
+		t.Errorf("Expected the caller to be the test, got %q", caller)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if args, ok := log.get("Slow query plan"); ok {
+			if plan, _ := attr(args, "plan").(string); !strings.Contains(plan, "IXSCAN user_id_1") {
+				t.Errorf("Expected the plan to use the user_id index, got %q", plan)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the plan of the slow query to be logged")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestOperationCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		op   clause.Operation
+		want string
+	}{
+		{
+			name: "Find with sort and limit",
+			op: clause.Operation{
+				Name:    "find",
+				Filter:  bson.M{"status": "active"},
+				Options: options.Find().SetSort(bson.D{{Key: "age", Value: -1}}).SetLimit(10),
+			},
+			want: `{"find":"","filter":{"status":"active"},"sort":{"age":-1},"limit":10}`,
+		},
+		{
+			name: "Delete one",
+			op:   clause.Operation{Name: "deleteOne", Filter: bson.M{"user_id": 1}},
+			want: `{"delete":"","deletes":[{"q":{"user_id":1},"limit":1}]}`,
+		},
+		{
+			name: "Update many",
+			op: clause.Operation{
+				Name:   "updateMany",
+				Filter: bson.M{"age": bson.M{"$lt": 18}},
+				Update: bson.M{"$set": bson.M{"minor": true}},
+			},
+			want: `{"update":"","updates":[{"q":{"age":{"$lt":18}},"u":{"$set":{"minor":true}},"multi":true}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := tt.op.Command()
+			if err != nil {
+				t.Fatalf("Command() error = %v", err)
+			}
+			data, err := bson.MarshalExtJSON(cmd, false, false)
+			if err != nil {
+				t.Fatalf("MarshalExtJSON() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Command() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+
+	if _, err := (&clause.Operation{Name: "drop"}).Command(); err == nil {
+		t.Error("Expected an error for an operation without command")
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/option"
+)
+
+func TestSnapshot(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	userID1, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Errorf("Failed to generate user ID: %v", err)
+	}
+	userID2, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Errorf("Failed to generate user ID: %v", err)
+	}
+
+	_, err = userDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: userID1})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	var count int64
+	atClusterTime, err := ins.Snapshot(context.Background(), func(ctx context.Context) error {
+		count, err = userDao.Ctx(ctx).MCount()
+		if err != nil {
+			return err
+		}
+
+		_, err := userDao.Ctx(ctx).MCreateOne(&User{Username: "user2", Email: "user2@example.com", UserID: userID2})
+		if !errors.Is(err, clause.ErrReadOnly) {
+			t.Errorf("MCreateOne() inside snapshot error = %v, want %v", err, clause.ErrReadOnly)
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if atClusterTime == nil {
+		t.Fatalf("Snapshot() returned no cluster time")
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 user in snapshot, got %d", count)
+	}
+
+	_, err = userDao.Clause().MCreateOne(&User{Username: "user2", Email: "user2@example.com", UserID: userID2})
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	// Reproduce the report at the same cluster time
+	_, err = ins.Snapshot(context.Background(), func(ctx context.Context) error {
+		result := &[]User{}
+		if err := userDao.Ctx(ctx).MFindMany(result); err != nil {
+			return err
+		}
+		if len(*result) != 1 {
+			t.Errorf("Expected 1 user at cluster time %v, got %d", atClusterTime, len(*result))
+		}
+		return nil
+	}, &option.SnapshotOption{AtClusterTime: atClusterTime})
+	if err != nil {
+		t.Errorf("Snapshot() at cluster time error = %v", err)
+	}
+}
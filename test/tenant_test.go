@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func TestTenantField(t *testing.T) {
+	ins := setupTestDB(t)
+	ins.SetTenancy(morn.TenantField("tenant_id"), nil)
+	userDao := InitUserModel(t, ins)
+	defer func() {
+		ins.SetTenancy(nil, nil)
+		cleanupTestDB(t, userDao, ins)
+	}()
+
+	ctxA := morn.WithTenant(context.Background(), "tenant-a")
+	ctxB := morn.WithTenant(context.Background(), "tenant-b")
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Errorf("Failed to generate user ID: %v", err)
+	}
+	_, err = userDao.Ctx(ctxA).MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: userID})
+	if err != nil {
+		t.Fatalf("Failed to create tenant user: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want int64
+	}{
+		{
+			name: "Owner tenant sees its document",
+			ctx:  ctxA,
+			want: 1,
+		},
+		{
+			name: "Other tenant does not see the document",
+			ctx:  ctxB,
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := userDao.Ctx(tt.ctx).Where(bson.M{"user_id": userID}).MCount()
+			if err != nil {
+				t.Fatalf("MCount() error = %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("MCount() = %d, want %d", count, tt.want)
+			}
+
+			result := &[]bson.M{}
+			err = userDao.Ctx(tt.ctx).MAggregate(result, []bson.M{{"$match": bson.M{"user_id": userID}}})
+			if err != nil {
+				t.Fatalf("MAggregate() error = %v", err)
+			}
+			if int64(len(*result)) != tt.want {
+				t.Errorf("MAggregate() returned %d documents, want %d", len(*result), tt.want)
+			}
+		})
+	}
+
+	err = userDao.Ctx(ctxB).Where(bson.M{"user_id": userID}).MUpdateOne(bson.M{"tenant_id": "tenant-b", "point": 10})
+	if err != nil {
+		t.Errorf("MUpdateOne() error = %v", err)
+	}
+	res, err := userDao.Ctx(ctxA).Where(bson.M{"user_id": userID}).FindOne()
+	if err != nil {
+		t.Fatalf("FindOne() error = %v", err)
+	}
+	stored := bson.M{}
+	if err := res.Decode(&stored); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if stored["tenant_id"] != "tenant-a" || stored["point"] == int64(10) {
+		t.Errorf("Other tenant modified the document: %v", stored)
+	}
+
+	if _, err := userDao.Clause().MCount(); !errors.Is(err, morn.ErrTenantRequired) {
+		t.Errorf("MCount() without tenant error = %v, want %v", err, morn.ErrTenantRequired)
+	}
+}
+
+func TestTenantUnitOfWork(t *testing.T) {
+	ins := setupTestDB(t)
+	ins.SetTenancy(morn.CollectionPrefix("_"), nil)
+	userDao := InitUserModel(t, ins)
+
+	ctxA := morn.WithTenant(context.Background(), "tenant-a")
+	ctxB := morn.WithTenant(context.Background(), "tenant-b")
+	defer func() {
+		for _, ctx := range []context.Context{ctxA, ctxB} {
+			if _, err := userDao.Ctx(ctx).MDeleteMany(); err != nil {
+				t.Errorf("failed to delete tenant data: %v", err)
+			}
+		}
+		ins.SetTenancy(nil, nil)
+		cleanupTestDB(t, userDao, ins)
+	}()
+
+	// the same _id exists in the collections of both tenants
+	id := bson.NewObjectID()
+	for _, ctx := range []context.Context{ctxA, ctxB} {
+		_, err := userDao.Ctx(ctx).MCreateOne(&User{ID: &id, Username: "user1", Email: "user1@example.com", UserID: 1})
+		if err != nil {
+			t.Fatalf("Failed to create tenant user: %v", err)
+		}
+	}
+
+	uow := morn.NewUnitOfWork(nil)
+	userA, err := morn.Load[User](ctxA, uow, userDao, id)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	userB, err := morn.Load[User](ctxB, uow, userDao, id)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if userA == userB {
+		t.Fatal("Load() returned the entity of another tenant")
+	}
+
+	userA.Point = 10
+	if err := uow.Commit(ctxA); !errors.Is(err, morn.ErrTenantMismatch) {
+		t.Errorf("Commit() with entities of two tenants error = %v, want %v", err, morn.ErrTenantMismatch)
+	}
+}
+
+func TestTenantBulkWrite(t *testing.T) {
+	unreachable, err := mongo.Connect(options.Client().
+		SetHosts([]string{"localhost:1"}).
+		SetServerSelectionTimeout(50 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer unreachable.Disconnect(context.Background())
+
+	ins, err := morn.FromClient(unreachable, morn.WithDatabase("Cluster0"), morn.WithTenancy(morn.TenantField("tenant_id"), nil))
+	if err != nil {
+		t.Fatalf("FromClient() error = %v", err)
+	}
+	var models []mongo.WriteModel
+	userDao := morn.NewDao(UserCollection, User{}, ins, nil).Use(func(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+		models = op.Models
+		op.Result = &mongo.BulkWriteResult{}
+		return nil
+	})
+
+	insert := bson.M{"username": "user1"}
+	given := []mongo.WriteModel{
+		mongo.NewInsertOneModel().SetDocument(insert),
+		mongo.NewUpdateOneModel().SetFilter(bson.M{"username": "user1"}).SetUpdate(bson.M{"$set": bson.M{"tenant_id": "other", "point": 1}}),
+		mongo.NewDeleteOneModel().SetFilter(bson.M{"username": "user2"}),
+	}
+	ctx := morn.WithTenant(context.Background(), "tenant-a")
+	if _, err := userDao.Ctx(ctx).BulkWrite(given); err != nil {
+		t.Fatalf("BulkWrite() error = %v", err)
+	}
+	if len(models) != 3 {
+		t.Fatalf("Expected 3 models, got %d", len(models))
+	}
+	doc, _ := models[0].(*mongo.InsertOneModel).Document.(bson.M)
+	if doc["tenant_id"] != "tenant-a" || doc["_id"] == nil {
+		t.Errorf("Expected the inserted document to hold the tenant and an _id, got %v", doc)
+	}
+	update := models[1].(*mongo.UpdateOneModel)
+	if filter, _ := update.Filter.(bson.M); filter["tenant_id"] != "tenant-a" {
+		t.Errorf("Expected the update filter to hold the tenant, got %v", update.Filter)
+	}
+	if set, _ := update.Update.(bson.M)["$set"].(bson.M); set["tenant_id"] != nil || set["point"] != 1 {
+		t.Errorf("Expected the update to keep the tenant, got %v", update.Update)
+	}
+	if filter, _ := models[2].(*mongo.DeleteOneModel).Filter.(bson.M); filter["tenant_id"] != "tenant-a" {
+		t.Errorf("Expected the delete filter to hold the tenant, got %v", models[2])
+	}
+	if _, ok := insert["tenant_id"]; ok {
+		t.Error("Expected the given models to be left unchanged")
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nghialthanh/morn-go"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestOperationTimeout(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Fatalf("Failed to generate user ID: %v", err)
+	}
+	if _, err := userDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: userID}); err != nil {
+		t.Fatalf("MCreateOne() error = %v", err)
+	}
+
+	opt := ins.GetOptsField()
+	opt.ReadTimeout = 50 * time.Millisecond
+	slowDao := morn.NewDao(UserCollection, User{}, ins, &opt)
+	slow := bson.M{"$where": "sleep(500) || true"}
+
+	tests := []struct {
+		name    string
+		count   func() (int64, error)
+		timeout bool
+	}{
+		{
+			name:    "Dao read timeout",
+			count:   func() (int64, error) { return slowDao.Clause().Where(slow).MCount() },
+			timeout: true,
+		},
+		{
+			name: "Clause timeout overrides the Dao timeout",
+			count: func() (int64, error) {
+				return slowDao.Clause().Where(slow).Timeout(5 * time.Second).MCount()
+			},
+		},
+		{
+			name: "Parent deadline is earlier than the clause timeout",
+			count: func() (int64, error) {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				return userDao.Ctx(ctx).Where(slow).Timeout(5 * time.Second).MCount()
+			},
+			timeout: true,
+		},
+		{
+			name:  "Timeout(0) removes the Dao timeout",
+			count: func() (int64, error) { return slowDao.Clause().Where(slow).Timeout(0).MCount() },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.count()
+			if tt.timeout && !mongo.IsTimeout(err) {
+				t.Errorf("Expected a timeout error, got %v", err)
+			}
+			if !tt.timeout && err != nil {
+				t.Errorf("MCount() error = %v", err)
+			}
+		})
+	}
+}
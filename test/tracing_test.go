@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanAttribute(span tracetest.SpanStub, key string) (attribute.Value, bool) {
+	for _, attr := range span.Attributes {
+		if string(attr.Key) == key {
+			return attr.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, span := range spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func TestTracingOperations(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	exporter := tracetest.NewInMemoryExporter()
+	ins.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	userID, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Fatalf("GenIDForDao() error = %v", err)
+	}
+	users := []User{
+		{Username: "user1", Email: "user1@example.com", UserID: userID},
+		{Username: "user2", Email: "user2@example.com", UserID: userID + 1},
+	}
+	if _, err := userDao.Clause().MCreateMany(users); err != nil {
+		t.Fatalf("MCreateMany() error = %v", err)
+	}
+	if _, err := userDao.Clause().Where(bson.M{"user_id": bson.M{"$gte": userID}}).MCount(); err != nil {
+		t.Fatalf("MCount() error = %v", err)
+	}
+	if _, err := userDao.Clause().MCreateOne(&User{Username: "user1", Email: "user1@example.com", UserID: userID}); err == nil {
+		t.Fatal("Expected a duplicate key error")
+	}
+
+	spans := exporter.GetSpans()
+	if _, ok := findSpan(spans, "genID "+UserCollection); !ok {
+		t.Error("Expected a span for GenIDForDao")
+	}
+
+	insert, ok := findSpan(spans, "insertMany "+UserCollection)
+	if !ok {
+		t.Fatal("Expected a span for MCreateMany")
+	}
+	expected := map[string]attribute.Value{
+		"db.system":                 attribute.StringValue("mongodb"),
+		"db.collection.name":        attribute.StringValue(UserCollection),
+		"db.operation.name":         attribute.StringValue("insertMany"),
+		"db.operation.batch.size":   attribute.IntValue(2),
+		"db.mongodb.inserted_count": attribute.IntValue(2),
+	}
+	for key, want := range expected {
+		if got, ok := spanAttribute(insert, key); !ok || got != want {
+			t.Errorf("Expected %s = %v, got %v", key, want.Emit(), got.Emit())
+		}
+	}
+
+	count, ok := findSpan(spans, "countDocuments "+UserCollection)
+	if !ok {
+		t.Fatal("Expected a span for MCount")
+	}
+	if got, _ := spanAttribute(count, "db.query.text"); got.AsString() != `{"user_id":{"$gte":"?"}}` {
+		t.Errorf("Expected the sanitized query shape, got %s", got.AsString())
+	}
+	if got, _ := spanAttribute(count, "db.response.returned_rows"); got.AsInt64() != 2 {
+		t.Errorf("Expected 2 returned rows, got %d", got.AsInt64())
+	}
+
+	failed, ok := findSpan(spans, "insertOne "+UserCollection)
+	if !ok {
+		t.Fatal("Expected a span for MCreateOne")
+	}
+	if failed.Status.Code != codes.Error {
+		t.Errorf("Expected the span of the duplicate insert to have an error status, got %v", failed.Status.Code)
+	}
+	if got, _ := spanAttribute(failed, "db.response.status_code"); got.AsString() != "11000" {
+		t.Errorf("Expected status code 11000, got %q", got.AsString())
+	}
+}
+
+func TestTracingSession(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ins.SetTracerProvider(provider)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	err := userDao.Session(ctx, func(ctx context.Context) error {
+		_, err := userDao.Ctx(ctx).MCreateOne(&User{Username: "user1", Email: "user1@example.com"})
+		return err
+	}, nil)
+	parent.End()
+	if err != nil {
+		t.Fatalf("Session() error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	transaction, ok := findSpan(spans, "transaction "+UserCollection)
+	if !ok {
+		t.Fatal("Expected a span for the transaction")
+	}
+	if transaction.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected the transaction span to be a child of the span of ctx")
+	}
+	if got, _ := spanAttribute(transaction, "db.mongodb.transaction.outcome"); got.AsString() != "committed" {
+		t.Errorf("Expected the transaction to be committed, got %q", got.AsString())
+	}
+
+	insert, ok := findSpan(spans, "insertOne "+UserCollection)
+	if !ok {
+		t.Fatal("Expected a span for MCreateOne")
+	}
+	if insert.Parent.SpanID() != transaction.SpanContext.SpanID() {
+		t.Error("Expected the operation span to be a child of the transaction span")
+	}
+}
+
+func TestQueryShape(t *testing.T) {
+	tests := []struct {
+		name string
+		op   clause.Operation
+		want string
+	}{
+		{name: "No filter", op: clause.Operation{}, want: ""},
+		{
+			name: "Operators and sorted keys",
+			op:   clause.Operation{Filter: bson.D{{Key: "status", Value: "active"}, {Key: "age", Value: bson.M{"$gt": 18}}}},
+			want: `{"age":{"$gt":"?"},"status":"?"}`,
+		},
+		{
+			name: "Logical operators keep their operands",
+			op:   clause.Operation{Filter: bson.M{"$or": bson.A{bson.M{"a": 1}, bson.M{"b": bson.M{"$in": bson.A{1, 2}}}}}},
+			want: `{"$or":[{"a":"?"},{"b":{"$in":"?"}}]}`,
+		},
+		{
+			name: "Pipeline",
+			op:   clause.Operation{Pipeline: []bson.M{{"$match": bson.M{"user_id": 1}}, {"$limit": 10}}},
+			want: `[{"$match":{"user_id":"?"}},{"$limit":"?"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.op.QueryShape(); got != tt.want {
+				t.Errorf("QueryShape() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/nghialthanh/morn-go"
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/option"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+
+	ctx := context.Background()
+
+	userID1, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Errorf("Failed to generate user ID: %v", err)
+	}
+	userID2, err := userDao.GenIDForDao()
+	if err != nil {
+		t.Errorf("Failed to generate user ID: %v", err)
+	}
+
+	id1 := bson.NewObjectID()
+	id2 := bson.NewObjectID()
+	_, err = userDao.Clause().MCreateMany([]*User{
+		{ID: &id1, Username: "user1", Email: "user1@example.com", UserID: userID1, Point: 10},
+		{ID: &id2, Username: "user2", Email: "user2@example.com", UserID: userID2, Point: 20},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create test users: %v", err)
+	}
+
+	uow := morn.NewUnitOfWork(nil)
+
+	user1, err := morn.Load[User](ctx, uow, userDao, id1)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	again, err := morn.Load[User](ctx, uow, userDao, id1)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if user1 != again {
+		t.Errorf("Load() returned a different pointer for the same document")
+	}
+
+	user1.Point = 99
+	if err := uow.RegisterDeleted(userDao, &User{ID: &id2}); err != nil {
+		t.Fatalf("RegisterDeleted() error = %v", err)
+	}
+	user3 := &User{Username: "user3", Email: "user3@example.com", UserID: userID2 + 1}
+	uow.RegisterNew(userDao, user3)
+
+	if err := uow.Commit(ctx); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	result := &[]User{}
+	if err := userDao.Clause().Sort("username:asc").MFindMany(result); err != nil {
+		t.Fatalf("Failed to verify commit: %v", err)
+	}
+	if len(*result) != 2 {
+		t.Fatalf("Expected 2 users after commit, got %d", len(*result))
+	}
+	if (*result)[0].Username != "user1" || (*result)[0].Point != 99 {
+		t.Errorf("Tracked user was not updated: %+v", (*result)[0])
+	}
+	if (*result)[1].Username != "user3" {
+		t.Errorf("New user was not inserted: %+v", (*result)[1])
+	}
+
+	// the inserted entity holds its _id and is tracked
+	if user3.ID == nil {
+		t.Fatal("Expected the _id of the new user to be written back")
+	}
+	loaded, err := morn.Load[User](ctx, uow, userDao, *user3.ID)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded != user3 {
+		t.Errorf("Load() returned a different pointer for the committed entity")
+	}
+	user3.Point = 42
+	if err := uow.Commit(ctx); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	stored := &User{}
+	if err := userDao.Clause().Where(bson.M{"_id": *user3.ID}).MFindOne(stored); err != nil {
+		t.Fatalf("MFindOne() error = %v", err)
+	}
+	if stored.Point != 42 {
+		t.Errorf("Changes to the committed entity were not flushed: %+v", stored)
+	}
+}
+
+func TestUnitOfWorkPipeline(t *testing.T) {
+	ins := setupTestDB(t)
+	userDao := InitUserModel(t, ins)
+	defer cleanupTestDB(t, userDao, ins)
+	defer ins.GetDB().Collection(auditCollection).Drop(context.Background())
+
+	opt := ins.GetOptsField()
+	opt.Audit = &option.AuditOption{Collection: auditCollection}
+	var names []string
+	auditedDao := morn.NewDao(UserCollection, HookedUser{}, ins, &opt).Use(func(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+		names = append(names, op.Name)
+		return next(ctx, op)
+	})
+	ctx := morn.WithActor(context.Background(), "alice")
+
+	user := &HookedUser{Username: "user1"}
+	uow := morn.NewUnitOfWork(nil)
+	uow.RegisterNew(auditedDao, user)
+	if err := uow.Commit(ctx); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if len(names) != 1 || names[0] != "bulkWrite" {
+		t.Errorf("Expected the flush to run through the interceptors, got %v", names)
+	}
+	if user.ID == nil {
+		t.Fatal("Expected the _id of the new user to be written back")
+	}
+	user.Email = "user1@example.com"
+	if err := uow.Commit(ctx); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	want := []string{"BeforeCreate", "AfterCreate", "BeforeUpdate", "AfterUpdate"}
+	if fmt.Sprint(user.Events) != fmt.Sprint(want) {
+		t.Errorf("Expected the hooks %v, got %v", want, user.Events)
+	}
+	records := auditRecords(t, ins, *user.ID)
+	if len(records) != 2 || records[0].Operation != "bulkWrite" || records[0].Actor != "alice" {
+		t.Errorf("Expected two audit records by alice, got %+v", records)
+	}
+	if len(records) == 2 && changedFields(records[1])["email"].After != "user1@example.com" {
+		t.Errorf("Expected the email change to be audited, got %+v", records[1])
+	}
+}
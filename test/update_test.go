@@ -11,7 +11,7 @@ import (
 func TestUpdateOne(t *testing.T) {
 	ins := setupTestDB(t)
 
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 	defer cleanupTestDB(t, userDao, ins)
 
 	// Create test data
@@ -85,7 +85,7 @@ func TestUpdateOne(t *testing.T) {
 func TestUpdateMany(t *testing.T) {
 	ins := setupTestDB(t)
 
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 	defer cleanupTestDB(t, userDao, ins)
 
 	// Create test data
@@ -167,7 +167,7 @@ func TestUpdateMany(t *testing.T) {
 func TestIncreaseValue(t *testing.T) {
 	ins := setupTestDB(t)
 
-	userDao := InitUserModel(ins)
+	userDao := InitUserModel(t, ins)
 	defer cleanupTestDB(t, userDao, ins)
 
 	// Create test data
@@ -240,7 +240,7 @@ func TestIncreaseValue(t *testing.T) {
 // func TestFindOneAndUpdate(t *testing.T) {
 // 	ins := setupTestDB(t)
 
-// 	userDao := InitUserModel(ins)
+// 	userDao := InitUserModel(t, ins)
 // 	defer cleanupTestDB(t, userDao, ins)
 
 // 	// Create test data
@@ -0,0 +1,154 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/nghialthanh/morn-go"
+
+// Span attributes, following the OpenTelemetry semantic conventions for database clients
+const (
+	attrDBSystem       = attribute.Key("db.system")
+	attrDBNamespace    = attribute.Key("db.namespace")
+	attrDBCollection   = attribute.Key("db.collection.name")
+	attrDBOperation    = attribute.Key("db.operation.name")
+	attrDBQueryText    = attribute.Key("db.query.text")
+	attrDBBatchSize    = attribute.Key("db.operation.batch.size")
+	attrDBReturnedRows = attribute.Key("db.response.returned_rows")
+	attrDBStatusCode   = attribute.Key("db.response.status_code")
+	attrErrorType      = attribute.Key("error.type")
+
+	attrMatchedCount  = attribute.Key("db.mongodb.matched_count")
+	attrModifiedCount = attribute.Key("db.mongodb.modified_count")
+	attrUpsertedCount = attribute.Key("db.mongodb.upserted_count")
+	attrInsertedCount = attribute.Key("db.mongodb.inserted_count")
+	attrDeletedCount  = attribute.Key("db.mongodb.deleted_count")
+	attrSequence      = attribute.Key("db.mongodb.sequence")
+	attrOutcome       = attribute.Key("db.mongodb.transaction.outcome")
+)
+
+// SetTracerProvider sets the provider of the spans of the Instance
+// Without one the global provider of otel is used, which records nothing until the application sets it.
+func (i *Instance) SetTracerProvider(provider trace.TracerProvider) *Instance {
+	i.tracerProvider = provider
+	return i
+}
+
+// WithTracerProvider sets the provider of the spans of the Instance, see Instance.SetTracerProvider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *setupConfig) error {
+		if provider == nil {
+			return errors.New("morn: WithTracerProvider: provider is nil")
+		}
+		c.tracerProvider = provider
+		return nil
+	}
+}
+
+func (i *Instance) tracer() trace.Tracer {
+	provider := i.tracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// traceOperation is the outermost interceptor of every clause, it records one client span per operation
+// The span is a child of the span of ctx, so operations run inside Dao.Session are children of the transaction.
+func (i *Instance) traceOperation(ctx context.Context, op *clause.Operation, next clause.Invoker) error {
+	ctx, span := i.tracer().Start(ctx, op.Name+" "+op.Collection.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attrDBSystem.String("mongodb"),
+			attrDBNamespace.String(op.Collection.Database().Name()),
+			attrDBCollection.String(op.Collection.Name()),
+			attrDBOperation.String(op.Name),
+		),
+	)
+	defer span.End()
+
+	err := next(ctx, op)
+	if !span.IsRecording() {
+		return err
+	}
+
+	if shape := op.QueryShape(); shape != "" {
+		span.SetAttributes(attrDBQueryText.String(shape))
+	}
+	if len(op.Documents) > 1 {
+		span.SetAttributes(attrDBBatchSize.Int(len(op.Documents)))
+	}
+	if len(op.Models) > 1 {
+		span.SetAttributes(attrDBBatchSize.Int(len(op.Models)))
+	}
+	span.SetAttributes(resultAttributes(op.Result)...)
+	recordError(span, err)
+	return err
+}
+
+// resultAttributes returns the document counts of the result of an operation
+func resultAttributes(result interface{}) []attribute.KeyValue {
+	switch res := result.(type) {
+	case int64:
+		return []attribute.KeyValue{attrDBReturnedRows.Int64(res)}
+	case *mongo.InsertOneResult:
+		return []attribute.KeyValue{attrInsertedCount.Int(1)}
+	case *mongo.InsertManyResult:
+		return []attribute.KeyValue{attrInsertedCount.Int(len(res.InsertedIDs))}
+	case *mongo.UpdateResult:
+		return []attribute.KeyValue{
+			attrMatchedCount.Int64(res.MatchedCount),
+			attrModifiedCount.Int64(res.ModifiedCount),
+			attrUpsertedCount.Int64(res.UpsertedCount),
+		}
+	case *mongo.DeleteResult:
+		return []attribute.KeyValue{attrDeletedCount.Int64(res.DeletedCount)}
+	case *mongo.BulkWriteResult:
+		return []attribute.KeyValue{
+			attrInsertedCount.Int64(res.InsertedCount),
+			attrMatchedCount.Int64(res.MatchedCount),
+			attrModifiedCount.Int64(res.ModifiedCount),
+			attrUpsertedCount.Int64(res.UpsertedCount),
+			attrDeletedCount.Int64(res.DeletedCount),
+		}
+	}
+	return nil
+}
+
+// recordError sets the error status of span, a missing document is an answer and not a failure
+func recordError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, mongo.ErrNoDocuments) {
+		return
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		span.SetAttributes(attrErrorType.String(cmdErr.Name), attrDBStatusCode.String(strconv.Itoa(int(cmdErr.Code))))
+	} else {
+		span.SetAttributes(attrErrorType.String(fmt.Sprintf("%T", err)))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// startSpan starts a span of the Dao, for the calls that do not go through a clause
+func (d *Dao) startSpan(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if d.ins == nil || d.collection == nil {
+		return ctx, noop.Span{}
+	}
+	attrs = append([]attribute.KeyValue{
+		attrDBSystem.String("mongodb"),
+		attrDBNamespace.String(d.collection.Database().Name()),
+	}, attrs...)
+	return d.ins.tracer().Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,614 @@
+package morn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nghialthanh/morn-go/clause"
+	"github.com/nghialthanh/morn-go/option"
+	"github.com/nghialthanh/morn-go/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrEntityWithoutID = errors.New("entity has no _id value")
+	ErrTenantMismatch  = errors.New("entity was loaded for another tenant")
+)
+
+// UnitOfWork tracks entities loaded or registered across Daos and flushes
+// every change in a single transaction with one bulk write per collection.
+// It also works as an identity map: an entity loaded twice is returned from memory.
+// The bulk writes run through Clause.BulkWrite, with the gates, interceptors, routes and timeouts of
+// their Dao, so they are traced, measured and audited like the other writes.
+// A UnitOfWork is safe for concurrent use but is meant to live for one use case.
+type UnitOfWork struct {
+	// commitMu serializes the commits, mu guards the lists and is never held while the database is written
+	commitMu sync.Mutex
+	mu       sync.Mutex
+	tracked  map[string]*uowEntry
+	entries  []*uowEntry
+	newList  []*uowEntry
+	dirty    []*uowEntry
+	deleted  []*uowEntry
+	sessOpts *option.SessionOption
+}
+
+type uowEntry struct {
+	dao      *Dao
+	key      string
+	id       interface{}
+	entity   interface{}
+	snapshot bson.M
+}
+
+func NewUnitOfWork(opt *option.SessionOption) *UnitOfWork {
+	return &UnitOfWork{
+		tracked:  make(map[string]*uowEntry),
+		sessOpts: opt,
+	}
+}
+
+// Load returns the entity with the given _id from the identity map or loads it through dao
+// The returned pointer is the same for every call with the same Dao and id,
+// and changes made to it are detected automatically on Commit.
+// Entities are tracked per tenant, the context of Commit must resolve to the tenant they were loaded for.
+func Load[T any](ctx context.Context, u *UnitOfWork, dao *Dao, id interface{}) (*T, error) {
+	key, err := uowKey(ctx, dao, id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	if entry, ok := u.tracked[key]; ok {
+		u.mu.Unlock()
+		entity, ok := entry.entity.(*T)
+		if !ok {
+			return nil, fmt.Errorf("entity %s is tracked as %T", key, entry.entity)
+		}
+		return entity, nil
+	}
+	u.mu.Unlock()
+
+	entity := new(T)
+	if err := dao.Ctx(ctx).Where(bson.M{"_id": id}).MFindOne(entity); err != nil {
+		return nil, err
+	}
+	snapshot, err := utils.ConvToBson(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	// another goroutine may have loaded the same document meanwhile
+	if entry, ok := u.tracked[key]; ok {
+		if tracked, ok := entry.entity.(*T); ok {
+			return tracked, nil
+		}
+		return nil, fmt.Errorf("entity %s is tracked as %T", key, entry.entity)
+	}
+	entry := &uowEntry{dao: dao, key: key, id: id, entity: entity, snapshot: snapshot}
+	u.tracked[key] = entry
+	u.entries = append(u.entries, entry)
+	return entity, nil
+}
+
+// RegisterNew schedules entity to be inserted on Commit
+// The document is rendered at Commit, so later changes to entity are included. An entity without _id gets
+// a new ObjectID, written back to its _id field. Once committed, an entity passed by pointer is tracked
+// like a loaded one: Load returns it and its later changes are detected.
+func (u *UnitOfWork) RegisterNew(dao *Dao, entity interface{}) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.newList = append(u.newList, &uowEntry{dao: dao, entity: entity})
+}
+
+// RegisterDirty schedules an entity that was not obtained through Load to be updated on Commit
+// Entities obtained through Load do not need to be registered, their changes are detected automatically
+// Warning:
+// - Without a snapshot every field of entity except _id is written with $set
+func (u *UnitOfWork) RegisterDirty(dao *Dao, entity interface{}) error {
+	id, err := entityID(entity)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.dirty = append(u.dirty, &uowEntry{dao: dao, id: id, entity: entity})
+	return nil
+}
+
+// RegisterDeleted schedules entity to be deleted by its _id on Commit
+// A tracked entity with the same _id stops being tracked once the deletion is committed
+func (u *UnitOfWork) RegisterDeleted(dao *Dao, entity interface{}) error {
+	id, err := entityID(entity)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.deleted = append(u.deleted, &uowEntry{dao: dao, id: id, entity: entity})
+	return nil
+}
+
+// Commit computes the minimal writes for every registered or tracked entity
+// and flushes them inside one transaction, one ordered bulk write per collection.
+// The Before hooks of the entities (BeforeCreate, BeforeUpdate, BeforeDelete) run with ctx before the
+// transaction, the After hooks once it is committed. Only the entities that changed are updated.
+// On success the tracked snapshots are refreshed and the pending lists are cleared, on failure the pending
+// lists are kept for the next Commit. Loads and registrations made during the Commit are not part of it.
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	u.commitMu.Lock()
+	defer u.commitMu.Unlock()
+
+	// the plan is built from the lists taken under the lock, hooks may use the UnitOfWork
+	u.mu.Lock()
+	pending := &uowPending{
+		newList: u.newList,
+		dirty:   u.dirty,
+		deleted: u.deleted,
+		entries: slices.Clone(u.entries),
+		tracked: maps.Clone(u.tracked),
+	}
+	u.newList, u.dirty, u.deleted = nil, nil, nil
+	u.mu.Unlock()
+
+	plan, err := u.flush(ctx, pending)
+	if err != nil {
+		u.mu.Lock()
+		u.newList = append(pending.newList, u.newList...)
+		u.dirty = append(pending.dirty, u.dirty...)
+		u.deleted = append(pending.deleted, u.deleted...)
+		u.mu.Unlock()
+		return err
+	}
+
+	u.mu.Lock()
+	for entry, snapshot := range plan.snapshots {
+		entry.snapshot = snapshot
+	}
+	for key := range plan.deletedKeys {
+		if entry, ok := u.tracked[key]; ok {
+			delete(u.tracked, key)
+			u.removeEntry(entry)
+		}
+	}
+	for _, entry := range plan.created {
+		if setEntityID(entry) {
+			if _, ok := u.tracked[entry.key]; !ok {
+				u.tracked[entry.key] = entry
+				u.entries = append(u.entries, entry)
+			}
+		}
+	}
+	u.mu.Unlock()
+
+	for _, after := range plan.after {
+		if err := after(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush runs the writes of pending in one transaction
+func (u *UnitOfWork) flush(ctx context.Context, pending *uowPending) (*uowPlan, error) {
+	plan, err := pending.plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.order) == 0 {
+		return plan, nil
+	}
+
+	first := plan.order[0]
+	for _, dao := range plan.order[1:] {
+		if dao.client != first.client {
+			return nil, errors.New("unit of work spans daos of different clients")
+		}
+	}
+
+	err = first.Session(ctx, func(ctx context.Context) error {
+		for _, dao := range plan.order {
+			if _, err := dao.newClause(ctx).BulkWrite(plan.models[dao]); err != nil {
+				return fmt.Errorf("flush %s: %w", dao.colName, err)
+			}
+		}
+		return nil
+	}, u.sessOpts)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Rollback forgets every pending change and tracked entity without touching the database
+func (u *UnitOfWork) Rollback() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.tracked = make(map[string]*uowEntry)
+	u.entries = nil
+	u.newList = nil
+	u.dirty = nil
+	u.deleted = nil
+}
+
+// uowPending is what a Commit flushes, taken from the UnitOfWork when it starts
+type uowPending struct {
+	newList []*uowEntry
+	dirty   []*uowEntry
+	deleted []*uowEntry
+	entries []*uowEntry
+	tracked map[string]*uowEntry
+}
+
+type uowPlan struct {
+	ctx         context.Context
+	order       []*Dao
+	models      map[*Dao][]mongo.WriteModel
+	collections map[*Dao]*mongo.Collection
+	scopes      map[*Dao]bson.M
+	snapshots   map[*uowEntry]bson.M
+	deletedKeys map[string]bool
+	// created are the entries of the inserted entities, tracked once committed
+	created []*uowEntry
+	// after are the After hooks of the entities, run once committed
+	after []func(ctx context.Context) error
+}
+
+// target resolves the collection and tenant scope of dao once per Commit
+func (p *uowPlan) target(dao *Dao) (bson.M, error) {
+	if _, ok := p.collections[dao]; ok {
+		return p.scopes[dao], nil
+	}
+	collection, scope, err := dao.resolve(p.ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.collections[dao] = collection
+	p.scopes[dao] = scope
+	return scope, nil
+}
+
+// key returns the identity map key of the document id of dao for the tenant of the Commit
+func (p *uowPlan) key(dao *Dao, id interface{}) (string, error) {
+	scope, err := p.target(dao)
+	if err != nil {
+		return "", err
+	}
+	if p.collections[dao] == nil {
+		return "", fmt.Errorf("%s: %w", dao.colName, ErrNoDatabase)
+	}
+	return targetKey(p.collections[dao], scope, id), nil
+}
+
+func (p *uowPlan) add(dao *Dao, model mongo.WriteModel) {
+	if _, ok := p.models[dao]; !ok {
+		p.order = append(p.order, dao)
+	}
+	p.models[dao] = append(p.models[dao], model)
+}
+
+// hook calls fn on entity when it implements the hook H
+func hook[H any](entity interface{}, fn func(hook H) error) error {
+	if h, ok := entity.(H); ok {
+		return fn(h)
+	}
+	return nil
+}
+
+// afterHook queues fn to run on entity once the Commit succeeded, when it implements the hook H
+func afterHook[H any](p *uowPlan, entity interface{}, fn func(ctx context.Context, hook H) error) {
+	if h, ok := entity.(H); ok {
+		p.after = append(p.after, func(ctx context.Context) error { return fn(ctx, h) })
+	}
+}
+
+// plan computes the writes of pending, the scope of the tenant is added by Clause.BulkWrite
+func (pending *uowPending) plan(ctx context.Context) (*uowPlan, error) {
+	plan := &uowPlan{
+		ctx:         ctx,
+		models:      make(map[*Dao][]mongo.WriteModel),
+		collections: make(map[*Dao]*mongo.Collection),
+		scopes:      make(map[*Dao]bson.M),
+		snapshots:   make(map[*uowEntry]bson.M),
+		deletedKeys: make(map[string]bool),
+	}
+	now := time.Now()
+
+	for _, entry := range pending.deleted {
+		key, err := plan.key(entry.dao, entry.id)
+		if err != nil {
+			return nil, err
+		}
+		plan.deletedKeys[key] = true
+	}
+
+	for _, entry := range pending.newList {
+		if err := hook(entry.entity, func(h clause.BeforeCreator) error { return h.BeforeCreate(ctx) }); err != nil {
+			return nil, err
+		}
+		doc, err := utils.ConvToBson(entry.entity)
+		if err != nil {
+			return nil, err
+		}
+		id, ok := doc["_id"]
+		if !ok || id == nil {
+			id = bson.NewObjectID()
+			doc["_id"] = id
+		}
+		key, err := plan.key(entry.dao, id)
+		if err != nil {
+			return nil, err
+		}
+		// the snapshot is the entity holding its _id, the fields set on the document only are not part of it
+		snapshot, err := utils.ConvToBson(entry.entity)
+		if err != nil {
+			return nil, err
+		}
+		snapshot["_id"] = id
+		if field := entry.dao.option.CreateAtField; field != "" {
+			doc[field] = now
+		}
+		plan.add(entry.dao, mongo.NewInsertOneModel().SetDocument(doc))
+		plan.created = append(plan.created, &uowEntry{dao: entry.dao, key: key, id: id, entity: entry.entity, snapshot: snapshot})
+		afterHook(plan, entry.entity, func(ctx context.Context, h clause.AfterCreator) error { return h.AfterCreate(ctx) })
+	}
+
+	for _, entry := range pending.entries {
+		key, err := plan.key(entry.dao, entry.id)
+		if err != nil {
+			return nil, err
+		}
+		if key != entry.key {
+			return nil, fmt.Errorf("entity %s: %w", entry.key, ErrTenantMismatch)
+		}
+		if plan.deletedKeys[key] {
+			continue
+		}
+		// the scope is part of the filter, an entity never moves to another tenant
+		scope := plan.scopes[entry.dao]
+		current, update, err := entityUpdate(entry, scope)
+		if err != nil {
+			return nil, err
+		}
+		if len(update) == 0 {
+			continue
+		}
+		// the hook may change the entity, the update is computed again after it
+		if h, ok := entry.entity.(clause.BeforeUpdater); ok {
+			if err := h.BeforeUpdate(ctx); err != nil {
+				return nil, err
+			}
+			if current, update, err = entityUpdate(entry, scope); err != nil {
+				return nil, err
+			}
+			if len(update) == 0 {
+				continue
+			}
+		}
+		stampUpdate(update, entry.dao.option.UpdateAtField, now)
+		plan.add(entry.dao, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": entry.id}).SetUpdate(update))
+		plan.snapshots[entry] = current
+		afterHook(plan, entry.entity, func(ctx context.Context, h clause.AfterUpdater) error { return h.AfterUpdate(ctx) })
+	}
+
+	for _, entry := range pending.dirty {
+		key, err := plan.key(entry.dao, entry.id)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := pending.tracked[key]; ok {
+			continue
+		}
+		if err := hook(entry.entity, func(h clause.BeforeUpdater) error { return h.BeforeUpdate(ctx) }); err != nil {
+			return nil, err
+		}
+		current, err := utils.ConvToBson(entry.entity)
+		if err != nil {
+			return nil, err
+		}
+		delete(current, "_id")
+		if len(current) == 0 {
+			continue
+		}
+		update := bson.M{"$set": current}
+		stampUpdate(update, entry.dao.option.UpdateAtField, now)
+		plan.add(entry.dao, mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": entry.id}).SetUpdate(update))
+		afterHook(plan, entry.entity, func(ctx context.Context, h clause.AfterUpdater) error { return h.AfterUpdate(ctx) })
+	}
+
+	for _, entry := range pending.deleted {
+		if err := hook(entry.entity, func(h clause.BeforeDeleter) error { return h.BeforeDelete(ctx) }); err != nil {
+			return nil, err
+		}
+		plan.add(entry.dao, mongo.NewDeleteOneModel().SetFilter(bson.M{"_id": entry.id}))
+		afterHook(plan, entry.entity, func(ctx context.Context, h clause.AfterDeleter) error { return h.AfterDelete(ctx) })
+	}
+
+	return plan, nil
+}
+
+// entityUpdate returns the current document of a tracked entry and the update turning its snapshot into it
+func entityUpdate(entry *uowEntry, scope bson.M) (bson.M, bson.M, error) {
+	current, err := utils.ConvToBson(entry.entity)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, diffDocument(withoutKeys(entry.snapshot, scope), withoutKeys(current, scope)), nil
+}
+
+// setEntityID writes the _id of a created entry to its entity when it has none
+// It reports whether the entity can be tracked, which requires a pointer or a map.
+func setEntityID(entry *uowEntry) bool {
+	if doc, ok := entry.entity.(bson.M); ok {
+		if doc["_id"] == nil {
+			doc["_id"] = entry.id
+		}
+		return true
+	}
+	value := reflect.ValueOf(entry.entity)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return false
+	}
+	value = value.Elem()
+	if value.Kind() != reflect.Struct {
+		return true
+	}
+	id := reflect.ValueOf(entry.id)
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("bson"), ",")
+		field := value.Field(i)
+		if name != "_id" || !field.CanSet() || !field.IsZero() {
+			continue
+		}
+		switch {
+		case field.Kind() == reflect.Ptr && id.Type().AssignableTo(field.Type().Elem()):
+			ptr := reflect.New(field.Type().Elem())
+			ptr.Elem().Set(id)
+			field.Set(ptr)
+		case id.Type().AssignableTo(field.Type()):
+			field.Set(id)
+		}
+	}
+	return true
+}
+
+func (u *UnitOfWork) removeEntry(entry *uowEntry) {
+	for i, e := range u.entries {
+		if e == entry {
+			u.entries = append(u.entries[:i], u.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func stampUpdate(update bson.M, field string, now time.Time) {
+	if field == "" {
+		return
+	}
+	set, ok := update["$set"].(bson.M)
+	if !ok {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set[field] = now
+	if unset, ok := update["$unset"].(bson.M); ok {
+		delete(unset, field)
+		if len(unset) == 0 {
+			delete(update, "$unset")
+		}
+	}
+}
+
+// diffDocument returns the $set/$unset update turning before into after
+// Embedded documents are compared field by field using dotted paths
+func diffDocument(before bson.M, after bson.M) bson.M {
+	set := bson.M{}
+	unset := bson.M{}
+	diffFields("", before, after, set, unset)
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+func diffFields(prefix string, before bson.M, after bson.M, set bson.M, unset bson.M) {
+	for key, value := range after {
+		if prefix == "" && key == "_id" {
+			continue
+		}
+		path := prefix + key
+		old, ok := before[key]
+		if !ok {
+			set[path] = value
+			continue
+		}
+		oldDoc, oldIsDoc := asDocument(old)
+		newDoc, newIsDoc := asDocument(value)
+		if oldIsDoc && newIsDoc {
+			diffFields(path+".", oldDoc, newDoc, set, unset)
+			continue
+		}
+		if !reflect.DeepEqual(old, value) {
+			set[path] = value
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			unset[prefix+key] = ""
+		}
+	}
+}
+
+func asDocument(value interface{}) (bson.M, bool) {
+	switch doc := value.(type) {
+	case bson.M:
+		return doc, true
+	case bson.D:
+		result := make(bson.M, len(doc))
+		for _, e := range doc {
+			result[e.Key] = e.Value
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+func entityID(entity interface{}) (interface{}, error) {
+	doc, err := utils.ConvToBson(entity)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := doc["_id"]
+	if !ok || id == nil {
+		return nil, ErrEntityWithoutID
+	}
+	return id, nil
+}
+
+// withoutKeys returns doc without the fields of keys, doc is not modified
+func withoutKeys(doc bson.M, keys bson.M) bson.M {
+	if len(keys) == 0 {
+		return doc
+	}
+	result := make(bson.M, len(doc))
+	for key, value := range doc {
+		if _, ok := keys[key]; !ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// uowKey returns the identity map key of the document id of dao for the tenant of ctx
+func uowKey(ctx context.Context, dao *Dao, id interface{}) (string, error) {
+	collection, scope, err := dao.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	if collection == nil {
+		return "", fmt.Errorf("%s: %w", dao.colName, ErrNoDatabase)
+	}
+	return targetKey(collection, scope, id), nil
+}
+
+// targetKey identifies the document id in collection within scope
+func targetKey(collection *mongo.Collection, scope bson.M, id interface{}) string {
+	return fmt.Sprintf("%s.%s%v/%T:%v", collection.Database().Name(), collection.Name(), scope, id, id)
+}